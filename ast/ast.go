@@ -2,6 +2,8 @@ package ast
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 
 	"github.com/self-sasi/monkey-interpreter/token"
 )
@@ -31,6 +33,11 @@ type Expression interface {
 // It consists of a sequence of statements.
 type Program struct {
 	Statements []Statement
+
+	// the comments of the source in order, if the lexer retained them.
+	// they are not part of any statement, and tools such as formatters
+	// attach each one to the node following its position.
+	Comments []token.Token
 }
 
 // Returns the literal value of the first token in the program,
@@ -139,3 +146,576 @@ func (expressionStatement *ExpressionStatement) String() string {
 	}
 	return ""
 }
+
+// Represents an integer literal like 5, 0xFF or 1_000.
+type IntegerLiteral struct {
+	Token token.Token // the token.INT token
+	Value int64
+}
+
+func (integerLiteral *IntegerLiteral) expressionNode() {}
+
+func (integerLiteral *IntegerLiteral) TokenLiteral() string { return integerLiteral.Token.Literal }
+
+func (integerLiteral *IntegerLiteral) String() string { return integerLiteral.Token.Literal }
+
+// Represents a floating-point literal like 3.14 or 1e-9.
+type FloatLiteral struct {
+	Token token.Token // the token.FLOAT token
+	Value float64
+}
+
+func (floatLiteral *FloatLiteral) expressionNode() {}
+
+func (floatLiteral *FloatLiteral) TokenLiteral() string { return floatLiteral.Token.Literal }
+
+func (floatLiteral *FloatLiteral) String() string { return floatLiteral.Token.Literal }
+
+// Represents a string literal like "foo".
+type StringLiteral struct {
+	Token token.Token // the token.STRING token
+	Value string      // the string's value, with escapes resolved
+}
+
+func (stringLiteral *StringLiteral) expressionNode() {}
+
+func (stringLiteral *StringLiteral) TokenLiteral() string { return stringLiteral.Token.Literal }
+
+func (stringLiteral *StringLiteral) String() string { return quoteString(stringLiteral.Value) }
+
+// Returns value as a Monkey string literal, escaping the chars that cannot
+// appear in it verbatim.
+func quoteString(value string) string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for _, char := range value {
+		switch {
+		case char == '"' || char == '\\':
+			out.WriteRune('\\')
+			out.WriteRune(char)
+		case char == '\n':
+			out.WriteString(`\n`)
+		case char == '\t':
+			out.WriteString(`\t`)
+		case char < ' ' || char == 0x7f:
+			out.WriteString(`\u{` + strconv.FormatInt(int64(char), 16) + `}`)
+		default:
+			out.WriteRune(char)
+		}
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
+// Represents a boolean literal, i.e, true or false.
+type Boolean struct {
+	Token token.Token // the token.TRUE or token.FALSE token
+	Value bool
+}
+
+func (boolean *Boolean) expressionNode() {}
+
+func (boolean *Boolean) TokenLiteral() string { return boolean.Token.Literal }
+
+func (boolean *Boolean) String() string { return boolean.Token.Literal }
+
+// Represents the null literal, i.e, the absence of a value.
+type NullLiteral struct {
+	Token token.Token // the token.NULL token
+}
+
+func (nullLiteral *NullLiteral) expressionNode() {}
+
+func (nullLiteral *NullLiteral) TokenLiteral() string { return nullLiteral.Token.Literal }
+
+func (nullLiteral *NullLiteral) String() string { return nullLiteral.Token.Literal }
+
+// Represents a prefix operator applied to an expression, like -x or !ok.
+type PrefixExpression struct {
+	Token    token.Token // the prefix token, e.g. !
+	Operator string
+	Right    Expression
+}
+
+func (prefixExpression *PrefixExpression) expressionNode() {}
+
+func (prefixExpression *PrefixExpression) TokenLiteral() string {
+	return prefixExpression.Token.Literal
+}
+
+func (prefixExpression *PrefixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(prefixExpression.Operator)
+	out.WriteString(prefixExpression.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// Represents a binary operator applied to two expressions, like a + b.
+type InfixExpression struct {
+	Token    token.Token // the operator token, e.g. +
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (infixExpression *InfixExpression) expressionNode() {}
+
+func (infixExpression *InfixExpression) TokenLiteral() string {
+	return infixExpression.Token.Literal
+}
+
+func (infixExpression *InfixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(infixExpression.Left.String())
+	out.WriteString(" " + infixExpression.Operator + " ")
+	out.WriteString(infixExpression.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// Represents a sequence of statements enclosed in braces, e.g, the body of
+// a function or a branch of an if expression.
+type BlockStatement struct {
+	Token      token.Token // the { token
+	Statements []Statement
+}
+
+func (blockStatement *BlockStatement) statementNode() {}
+
+func (blockStatement *BlockStatement) TokenLiteral() string {
+	return blockStatement.Token.Literal
+}
+
+func (blockStatement *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	for _, s := range blockStatement.Statements {
+		out.WriteString(s.String())
+	}
+
+	return out.String()
+}
+
+// Represents an `if` expression with an optional `else` branch. An
+// `else if` chain is represented by an Alternative block holding the
+// nested IfExpression as its only statement.
+type IfExpression struct {
+	Token       token.Token // the 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement // nil if there is no else branch
+}
+
+func (ifExpression *IfExpression) expressionNode() {}
+
+func (ifExpression *IfExpression) TokenLiteral() string {
+	return ifExpression.Token.Literal
+}
+
+func (ifExpression *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("if")
+	out.WriteString(ifExpression.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ifExpression.Consequence.String())
+
+	if ifExpression.Alternative != nil {
+		out.WriteString("else ")
+		out.WriteString(ifExpression.Alternative.String())
+	}
+
+	return out.String()
+}
+
+// Represents a conditional expression like x > 0 ? x : -x.
+type ConditionalExpression struct {
+	Token       token.Token // the ? token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (conditionalExpression *ConditionalExpression) expressionNode() {}
+
+func (conditionalExpression *ConditionalExpression) TokenLiteral() string {
+	return conditionalExpression.Token.Literal
+}
+
+func (conditionalExpression *ConditionalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(conditionalExpression.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(conditionalExpression.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(conditionalExpression.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// Represents a loop like while (x < 10) { x += 1; }.
+type WhileStatement struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (whileStatement *WhileStatement) statementNode() {}
+
+func (whileStatement *WhileStatement) TokenLiteral() string {
+	return whileStatement.Token.Literal
+}
+
+func (whileStatement *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while (")
+	out.WriteString(whileStatement.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(whileStatement.Body.String())
+
+	return out.String()
+}
+
+// Represents a C-style loop like for (let i = 0; i < n; i += 1) { ... }.
+// Each of the three clauses may be left out.
+type ForStatement struct {
+	Token     token.Token // the 'for' token
+	Init      Statement   // a let or expression statement, nil if absent
+	Condition Expression  // nil if absent
+	Update    Expression  // nil if absent
+	Body      *BlockStatement
+}
+
+func (forStatement *ForStatement) statementNode() {}
+
+func (forStatement *ForStatement) TokenLiteral() string {
+	return forStatement.Token.Literal
+}
+
+func (forStatement *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if forStatement.Init != nil {
+		out.WriteString(strings.TrimSuffix(forStatement.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if forStatement.Condition != nil {
+		out.WriteString(forStatement.Condition.String())
+	}
+	out.WriteString("; ")
+	if forStatement.Update != nil {
+		out.WriteString(forStatement.Update.String())
+	}
+	out.WriteString(") ")
+	out.WriteString(forStatement.Body.String())
+
+	return out.String()
+}
+
+// Represents a loop over the elements of a collection like
+// for (x in [1, 2, 3]) { puts(x); }.
+type ForInStatement struct {
+	Token    token.Token // the 'for' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (forInStatement *ForInStatement) statementNode() {}
+
+func (forInStatement *ForInStatement) TokenLiteral() string {
+	return forInStatement.Token.Literal
+}
+
+func (forInStatement *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(forInStatement.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(forInStatement.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(forInStatement.Body.String())
+
+	return out.String()
+}
+
+// Represents a `break` out of the innermost loop.
+type BreakStatement struct {
+	Token token.Token // the 'break' token
+}
+
+func (breakStatement *BreakStatement) statementNode() {}
+
+func (breakStatement *BreakStatement) TokenLiteral() string {
+	return breakStatement.Token.Literal
+}
+
+func (breakStatement *BreakStatement) String() string {
+	return breakStatement.Token.Literal + ";"
+}
+
+// Represents a `continue` with the next iteration of the innermost loop.
+type ContinueStatement struct {
+	Token token.Token // the 'continue' token
+}
+
+func (continueStatement *ContinueStatement) statementNode() {}
+
+func (continueStatement *ContinueStatement) TokenLiteral() string {
+	return continueStatement.Token.Literal
+}
+
+func (continueStatement *ContinueStatement) String() string {
+	return continueStatement.Token.Literal + ";"
+}
+
+// Represents a function literal like fn(x, y) { x + y; }.
+type FunctionLiteral struct {
+	Token      token.Token // the 'fn' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (functionLiteral *FunctionLiteral) expressionNode() {}
+
+func (functionLiteral *FunctionLiteral) TokenLiteral() string {
+	return functionLiteral.Token.Literal
+}
+
+func (functionLiteral *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range functionLiteral.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(functionLiteral.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(functionLiteral.Body.String())
+
+	return out.String()
+}
+
+// Represents a call like add(1, 2), where Function is the expression
+// being called (an identifier or a function literal).
+//
+// A pipe like x |> add(1) is the call add(x, 1), with Piped set so that it
+// prints the way it was written. A pipe into a bare function like x |> f
+// is the call f(x), with the |> as Token, and so is a pipe into a call in
+// parentheses like x |> (makeAdder(1)), which calls the result.
+type CallExpression struct {
+	Token     token.Token // the ( token, or the |> token of a pipe without parentheses
+	Function  Expression
+	Arguments []Expression
+	Piped     bool // written as Arguments[0] |> Function(Arguments[1:]...)
+	Grouped   bool // written in parentheses, e.g, (makeAdder(1))
+}
+
+func (callExpression *CallExpression) expressionNode() {}
+
+func (callExpression *CallExpression) TokenLiteral() string {
+	return callExpression.Token.Literal
+}
+
+func (callExpression *CallExpression) String() string {
+	var out bytes.Buffer
+
+	arguments := callExpression.Arguments
+	if callExpression.Piped {
+		out.WriteString("(")
+		out.WriteString(arguments[0].String())
+		out.WriteString(" |> ")
+		arguments = arguments[1:]
+	}
+
+	args := []string{}
+	for _, a := range arguments {
+		args = append(args, a.String())
+	}
+
+	if call, ok := callExpression.Function.(*CallExpression); ok && call.Grouped && !call.Piped &&
+		callExpression.Token.Type == token.PIPE {
+		// keeps x |> (f(1)) apart from x |> f(1)
+		out.WriteString("(" + call.String() + ")")
+	} else {
+		out.WriteString(callExpression.Function.String())
+	}
+	if callExpression.Token.Type != token.PIPE {
+		out.WriteString("(")
+		out.WriteString(strings.Join(args, ", "))
+		out.WriteString(")")
+	}
+
+	if callExpression.Piped {
+		out.WriteString(")")
+	}
+
+	return out.String()
+}
+
+// Represents an array literal like [1, 2, 3].
+type ArrayLiteral struct {
+	Token    token.Token // the [ token
+	Elements []Expression
+}
+
+func (arrayLiteral *ArrayLiteral) expressionNode() {}
+
+func (arrayLiteral *ArrayLiteral) TokenLiteral() string {
+	return arrayLiteral.Token.Literal
+}
+
+func (arrayLiteral *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range arrayLiteral.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// Represents a key-value pair of a [HashLiteral].
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// Represents a hash literal like {"name": "SaSi", "age": 28}. Pairs are
+// kept in source order.
+type HashLiteral struct {
+	Token token.Token // the { token
+	Pairs []HashPair
+}
+
+func (hashLiteral *HashLiteral) expressionNode() {}
+
+func (hashLiteral *HashLiteral) TokenLiteral() string {
+	return hashLiteral.Token.Literal
+}
+
+func (hashLiteral *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hashLiteral.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Represents an index access like myArray[0] or sasi["name"].
+type IndexExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Index Expression
+}
+
+func (indexExpression *IndexExpression) expressionNode() {}
+
+func (indexExpression *IndexExpression) TokenLiteral() string {
+	return indexExpression.Token.Literal
+}
+
+func (indexExpression *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(indexExpression.Left.String())
+	out.WriteString("[")
+	out.WriteString(indexExpression.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// Represents an assignment like x = 5 or arr[i] += 2, where Target is an
+// [Identifier] or an [IndexExpression].
+type AssignExpression struct {
+	Token    token.Token // the = or compound assignment token, e.g, +=
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (assignExpression *AssignExpression) expressionNode() {}
+
+func (assignExpression *AssignExpression) TokenLiteral() string {
+	return assignExpression.Token.Literal
+}
+
+func (assignExpression *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(assignExpression.Target.String())
+	out.WriteString(" " + assignExpression.Operator + " ")
+	out.WriteString(assignExpression.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// Represents a template string like `hello ${name}!`, i.e, literal text
+// chunks interleaved with embedded expressions. Strings always holds one
+// more chunk than Expressions: Strings[i] comes before Expressions[i], and
+// the last chunk comes after the last expression. Chunks may be empty.
+type TemplateLiteral struct {
+	Token       token.Token // the token.TEMPLATE_START token
+	Strings     []string    // the literal text chunks, with escapes resolved
+	Expressions []Expression
+}
+
+func (templateLiteral *TemplateLiteral) expressionNode() {}
+
+func (templateLiteral *TemplateLiteral) TokenLiteral() string {
+	return templateLiteral.Token.Literal
+}
+
+// escapes the chars that would otherwise end a template text chunk
+var templateTextEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${")
+
+func (templateLiteral *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("`")
+	for i, chunk := range templateLiteral.Strings {
+		out.WriteString(templateTextEscaper.Replace(chunk))
+		if i < len(templateLiteral.Expressions) {
+			out.WriteString("${")
+			out.WriteString(templateLiteral.Expressions[i].String())
+			out.WriteString("}")
+		}
+	}
+	out.WriteString("`")
+
+	return out.String()
+}
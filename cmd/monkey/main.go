@@ -8,7 +8,22 @@ import (
 	"github.com/self-sasi/monkey-interpreter/repl"
 )
 
+const usage = `usage:
+  monkey                                     start the REPL
+  monkey tokens [-json] [-comments] [file]   print the tokens of file (or stdin)
+`
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tokens":
+			os.Exit(runTokens(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		default:
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/self-sasi/monkey-interpreter/lexer"
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Represents a token as emitted by "monkey tokens -json".
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	File    string          `json:"file,omitempty"`
+	Line    int             `json:"line"`
+	Column  int             `json:"column"`
+	Offset  int             `json:"offset"`
+}
+
+// Runs the "tokens" subcommand, which lexes a file (or stdin when no file
+// or "-" is given) and prints one token per line, either as text
+// ("line:column TYPE literal") or as JSON Lines. Lexical errors are
+// printed to stderr once the whole input has been lexed. Returns the exit
+// status.
+func runTokens(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print tokens as JSON Lines")
+	withComments := flags.Bool("comments", false, "include COMMENT tokens")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: monkey tokens [-json] [-comments] [file]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := stdin
+	options := []lexer.Option{}
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer file.Close()
+
+		input = file
+		options = append(options, lexer.WithFileName(path))
+	}
+	if *withComments {
+		options = append(options, lexer.WithComments())
+	}
+
+	lex := lexer.NewReader(input, options...)
+	encoder := json.NewEncoder(stdout)
+	encoder.SetEscapeHTML(false)
+
+	for {
+		tok := lex.NextToken()
+
+		if *asJSON {
+			err := encoder.Encode(jsonToken{
+				Type:    tok.Type,
+				Literal: tok.Literal,
+				File:    tok.Position.File,
+				Line:    tok.Position.Line,
+				Column:  tok.Position.Column,
+				Offset:  tok.Position.Offset,
+			})
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 1
+			}
+		} else {
+			fmt.Fprintf(stdout, "%d:%d\t%s\t%q\n",
+				tok.Position.Line, tok.Position.Column, tok.Type, tok.Literal)
+		}
+
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	if err := lex.Err(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	for _, err := range lex.Errors() {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+	}
+	if len(lex.Errors()) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTokens(t *testing.T) {
+	input := "let x = \"a&b\"; // note\nx & 1"
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedStatus int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			name:           "text",
+			args:           []string{},
+			expectedStatus: 1,
+			expectedStdout: `1:1	LET	"let"
+1:5	IDENT	"x"
+1:7	=	"="
+1:9	STRING	"a&b"
+1:14	;	";"
+2:1	IDENT	"x"
+2:3	ILLEGAL	"&"
+2:5	INT	"1"
+2:6	EOF	""
+`,
+			expectedStderr: "error: 2:3: illegal character '&' (did you mean '&&'?)\n",
+		},
+		{
+			name:           "json with comments",
+			args:           []string{"-json", "-comments", "-"},
+			expectedStatus: 1,
+			expectedStdout: `{"type":"LET","literal":"let","line":1,"column":1,"offset":0}
+{"type":"IDENT","literal":"x","line":1,"column":5,"offset":4}
+{"type":"=","literal":"=","line":1,"column":7,"offset":6}
+{"type":"STRING","literal":"a&b","line":1,"column":9,"offset":8}
+{"type":";","literal":";","line":1,"column":14,"offset":13}
+{"type":"COMMENT","literal":"// note","line":1,"column":16,"offset":15}
+{"type":"IDENT","literal":"x","line":2,"column":1,"offset":23}
+{"type":"ILLEGAL","literal":"&","line":2,"column":3,"offset":25}
+{"type":"INT","literal":"1","line":2,"column":5,"offset":27}
+{"type":"EOF","literal":"","line":2,"column":6,"offset":28}
+`,
+			expectedStderr: "error: 2:3: illegal character '&' (did you mean '&&'?)\n",
+		},
+		{
+			name:           "too many arguments",
+			args:           []string{"a.mk", "b.mk"},
+			expectedStatus: 2,
+			expectedStdout: "",
+			expectedStderr: "usage: monkey tokens [-json] [-comments] [file]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := runTokens(tt.args, strings.NewReader(input), &stdout, &stderr)
+
+		if status != tt.expectedStatus {
+			t.Errorf("%s: status wrong. expected=%d, got=%d", tt.name, tt.expectedStatus, status)
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%s: stdout wrong.\nexpected=%q\ngot=     %q", tt.name, tt.expectedStdout, stdout.String())
+		}
+		if !strings.HasPrefix(stderr.String(), tt.expectedStderr) {
+			t.Errorf("%s: stderr wrong.\nexpected=%q\ngot=     %q", tt.name, tt.expectedStderr, stderr.String())
+		}
+	}
+}
+
+func TestRunTokensFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.mk")
+	if err := os.WriteFile(path, []byte("add(1, 2)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	status := runTokens([]string{"-json", path}, strings.NewReader(""), &stdout, &stderr)
+
+	if status != 0 {
+		t.Fatalf("status wrong. expected=0, got=%d, stderr=%q", status, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("wrong number of tokens. expected=7, got=%d", len(lines))
+	}
+
+	expected := `{"type":"IDENT","literal":"add","file":"` + path + `","line":1,"column":1,"offset":0}`
+	if lines[0] != expected {
+		t.Errorf("first token wrong.\nexpected=%q\ngot=     %q", expected, lines[0])
+	}
+}
@@ -0,0 +1,110 @@
+package diagnostics
+
+import (
+	"fmt"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Represents how serious a [Diagnostic] is.
+type Severity int
+
+// Severities, from most to least serious
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+// Returns the severity as shown in rendered diagnostics, e.g, "error".
+func (severity Severity) String() string {
+	switch severity {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(severity))
+	}
+}
+
+// Diagnostic codes. Codes never change meaning once assigned, so tools can
+// filter on them; lexer codes start with L, parser codes with P.
+const (
+	IllegalCharacter     = "L0001"
+	UnterminatedString   = "L0002"
+	UnterminatedComment  = "L0003"
+	UnterminatedTemplate = "L0004"
+	MalformedNumber      = "L0005"
+	InvalidEscape        = "L0006"
+
+	UnexpectedToken         = "P0001"
+	InvalidLiteral          = "P0002"
+	EmptyTemplateExpr       = "P0003"
+	UnclosedBlock           = "P0004"
+	ExpectedExpression      = "P0005"
+	InvalidAssignmentTarget = "P0006"
+	LoopControlOutsideLoop  = "P0007"
+	NestingTooDeep          = "P0008"
+	FeatureDisabled         = "P0009"
+)
+
+// Represents a range of source text, from Start up to but not including
+// End. An empty span (Start == End) points between two chars.
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+// Creates the span of text, assuming it starts at start.
+func SpanOf(start token.Position, text string) Span {
+	end := start
+	for _, char := range text {
+		if char == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+	end.Offset += len(text)
+
+	return Span{Start: start, End: end}
+}
+
+// Represents a suggested edit that fixes a [Diagnostic]: replacing the text
+// in Span with Replacement. Insertions use an empty span.
+type Fix struct {
+	Message     string // what the fix does, e.g, `insert ")"`
+	Span        Span
+	Replacement string
+}
+
+// Represents a problem found in the source, with everything needed to
+// report it to a user or hand it over to tooling.
+type Diagnostic struct {
+	Severity Severity
+	Code     string   // stable identifier of the kind of problem, e.g, "P0001"
+	Message  string   // what is wrong, e.g, "expected next token to be ), got ; instead"
+	Span     Span     // the offending source text
+	Notes    []string // additional context, e.g, where an unclosed block was opened
+	Fix      *Fix     // a suggested fix (may be nil)
+}
+
+// Creates an error [Diagnostic] with a formatted message.
+func Errorf(code string, span Span, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Span:     span,
+	}
+}
+
+// Returns the diagnostic formatted as "position: message", so it reads like
+// the plain error strings it replaces.
+func (diagnostic *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s", diagnostic.Span.Start, diagnostic.Message)
+}
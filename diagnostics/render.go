@@ -0,0 +1,115 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Writes the diagnostic to w in the style of rustc and clang: a header
+// with severity, code and message, the location, the offending line of
+// source with the span underlined by carets, followed by notes and the
+// suggested fix, e.g,
+//
+//	error[P0001]: expected next token to be =, got INT instead
+//	 --> main.mk:1:7
+//	  |
+//	1 | let x 5;
+//	  |       ^
+//	  = help: insert "="
+//
+// source is the text the diagnostic was reported against; the source
+// excerpt is left out if it does not contain the line.
+func Render(w io.Writer, source string, diagnostic *Diagnostic) error {
+	start := diagnostic.Span.Start
+	line, ok := sourceLine(source, start)
+
+	gutter := strings.Repeat(" ", len(strconv.Itoa(start.Line)))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s[%s]: %s\n", diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+	fmt.Fprintf(&out, "%s--> %s\n", gutter, start)
+
+	if ok {
+		fmt.Fprintf(&out, "%s |\n", gutter)
+		fmt.Fprintf(&out, "%d | %s\n", start.Line, line)
+		fmt.Fprintf(&out, "%s | %s\n", gutter, underline(line, diagnostic.Span))
+	}
+
+	for _, note := range diagnostic.Notes {
+		fmt.Fprintf(&out, "%s = note: %s\n", gutter, note)
+	}
+	if diagnostic.Fix != nil {
+		fmt.Fprintf(&out, "%s = help: %s\n", gutter, diagnostic.Fix.Message)
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Returns the line of source containing position without its line
+// terminator. The line is found around the offset of position, so that
+// rendering every diagnostic of a file does not scan the file each time;
+// only positions with an offset outside source are looked up by line
+// number.
+func sourceLine(source string, position token.Position) (string, bool) {
+	offset := position.Offset
+	if offset < 0 || offset > len(source) {
+		return lineByNumber(source, position.Line)
+	}
+
+	start := strings.LastIndexByte(source[:offset], '\n') + 1
+	end := len(source)
+	if index := strings.IndexByte(source[offset:], '\n'); index >= 0 {
+		end = offset + index
+	}
+	return strings.TrimSuffix(source[start:end], "\r"), true
+}
+
+// Returns the 1-based line number of source without its line terminator.
+func lineByNumber(source string, number int) (string, bool) {
+	if number < 1 {
+		return "", false
+	}
+	for ; number > 1; number-- {
+		index := strings.IndexByte(source, '\n')
+		if index < 0 {
+			return "", false
+		}
+		source = source[index+1:]
+	}
+	if index := strings.IndexByte(source, '\n'); index >= 0 {
+		source = source[:index]
+	}
+	return strings.TrimSuffix(source, "\r"), true
+}
+
+// Returns the caret line marking span within line. Spans running past the
+// end of the line are cut off there, and empty spans get a single caret.
+func underline(line string, span Span) string {
+	chars := []rune(line)
+
+	var out strings.Builder
+	for i := 0; i < span.Start.Column-1; i++ {
+		// keep tabs so the carets line up with the source above
+		if i < len(chars) && chars[i] == '\t' {
+			out.WriteRune('\t')
+		} else {
+			out.WriteRune(' ')
+		}
+	}
+
+	width := len(chars) - (span.Start.Column - 1)
+	if span.End.Line == span.Start.Line {
+		width = span.End.Column - span.Start.Column
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	out.WriteString(strings.Repeat("^", width))
+	return out.String()
+}
@@ -0,0 +1,142 @@
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+func TestSpanOf(t *testing.T) {
+	start := token.Position{File: "main.mk", Line: 2, Column: 5, Offset: 10}
+
+	tests := []struct {
+		text        string
+		expectedEnd token.Position
+	}{
+		{"", token.Position{File: "main.mk", Line: 2, Column: 5, Offset: 10}},
+		{"let", token.Position{File: "main.mk", Line: 2, Column: 8, Offset: 13}},
+		{"héllo", token.Position{File: "main.mk", Line: 2, Column: 10, Offset: 16}},
+		{"/* a\nbc */", token.Position{File: "main.mk", Line: 3, Column: 6, Offset: 20}},
+	}
+
+	for i, tt := range tests {
+		span := SpanOf(start, tt.text)
+
+		if span.Start != start {
+			t.Fatalf("tests[%d] - start wrong. expected=%+v, got=%+v", i, start, span.Start)
+		}
+		if span.End != tt.expectedEnd {
+			t.Fatalf("tests[%d] - end wrong. expected=%+v, got=%+v", i, tt.expectedEnd, span.End)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	at := func(line, column, offset int) token.Position {
+		return token.Position{File: "main.mk", Line: line, Column: column, Offset: offset}
+	}
+
+	tests := []struct {
+		source     string
+		diagnostic *Diagnostic
+		expected   string
+	}{
+		{
+			"let x 5;",
+			&Diagnostic{
+				Severity: Error,
+				Code:     UnexpectedToken,
+				Message:  "expected next token to be =, got INT instead",
+				Span:     Span{Start: at(1, 7, 6), End: at(1, 8, 7)},
+				Fix:      &Fix{Message: `insert "="`, Span: Span{Start: at(1, 6, 5), End: at(1, 6, 5)}, Replacement: "="},
+			},
+			`error[P0001]: expected next token to be =, got INT instead
+ --> main.mk:1:7
+  |
+1 | let x 5;
+  |       ^
+  = help: insert "="
+`,
+		},
+		{
+			"let a = 1;\n\tlet b = 0xZZ;\r\n",
+			&Diagnostic{
+				Severity: Warning,
+				Code:     MalformedNumber,
+				Message:  "invalid character 'Z' in hexadecimal literal",
+				Span:     Span{Start: at(2, 10, 20), End: at(2, 14, 24)},
+				Notes:    []string{"first note", "second note"},
+			},
+			"warning[L0005]: invalid character 'Z' in hexadecimal literal\n" +
+				" --> main.mk:2:10\n" +
+				"  |\n" +
+				"2 | \tlet b = 0xZZ;\n" +
+				"  | \t        ^^^^\n" +
+				"  = note: first note\n" +
+				"  = note: second note\n",
+		},
+		{
+			// spans running past the end of the line are cut off there
+			strings.Repeat("\n", 9) + "/* never\nclosed",
+			&Diagnostic{
+				Severity: Error,
+				Code:     UnterminatedComment,
+				Message:  "unterminated block comment",
+				Span:     Span{Start: at(10, 1, 9), End: at(11, 7, 24)},
+			},
+			`error[L0003]: unterminated block comment
+  --> main.mk:10:1
+   |
+10 | /* never
+   | ^^^^^^^^
+`,
+		},
+		{
+			// empty spans, e.g, at EOF, still get a caret
+			"fn() {",
+			&Diagnostic{
+				Severity: Error,
+				Code:     UnclosedBlock,
+				Message:  "expected } to close the block, got EOF instead",
+				Span:     Span{Start: at(1, 7, 6), End: at(1, 7, 6)},
+			},
+			`error[P0004]: expected } to close the block, got EOF instead
+ --> main.mk:1:7
+  |
+1 | fn() {
+  |       ^
+`,
+		},
+		{
+			// a position without a valid offset is looked up by line
+			"a\nbc",
+			&Diagnostic{Severity: Error, Code: "X0000", Message: "no offset", Span: Span{Start: at(2, 2, -1), End: at(2, 3, -1)}},
+			`error[X0000]: no offset
+ --> main.mk:2:2
+  |
+2 | bc
+  |  ^
+`,
+		},
+		{
+			// the source excerpt is left out when the line is unknown
+			"x",
+			&Diagnostic{Severity: Note, Code: "X0000", Message: "somewhere else", Span: Span{Start: at(5, 1, 20), End: at(5, 2, 21)}},
+			`note[X0000]: somewhere else
+ --> main.mk:5:1
+`,
+		},
+	}
+
+	for i, tt := range tests {
+		var out strings.Builder
+		if err := Render(&out, tt.source, tt.diagnostic); err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %s", i, err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("tests[%d] - rendered diagnostic wrong.\nexpected:\n%s\ngot:\n%s", i, tt.expected, out.String())
+		}
+	}
+}
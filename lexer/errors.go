@@ -0,0 +1,115 @@
+package lexer
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Represents the kind of a lexical [Error].
+type ErrorKind string
+
+// Lexical error kinds
+const (
+	IllegalCharacter     ErrorKind = "illegal character"
+	UnterminatedString   ErrorKind = "unterminated string"
+	UnterminatedComment  ErrorKind = "unterminated comment"
+	UnterminatedTemplate ErrorKind = "unterminated template"
+	MalformedNumber      ErrorKind = "malformed number"
+	InvalidEscape        ErrorKind = "invalid escape"
+)
+
+// Represents a problem found while lexing. The lexer records the error and
+// carries on, so all problems in the input can be reported at once.
+type Error struct {
+	Kind     ErrorKind
+	Position token.Position // where the offending text starts
+	Text     string         // the offending source text
+	Message  string         // what is wrong, e.g, "invalid character 'Z' in hexadecimal literal"
+	Hint     string         // how to fix it, e.g, "did you mean '=='?" (may be empty)
+}
+
+// Returns the error formatted as "position: message (hint)".
+func (err Error) Error() string {
+	if err.Hint != "" {
+		return fmt.Sprintf("%s: %s (%s)", err.Position, err.Message, err.Hint)
+	}
+	return fmt.Sprintf("%s: %s", err.Position, err.Message)
+}
+
+// diagnostic code of every error kind
+var errorCodes = map[ErrorKind]string{
+	IllegalCharacter:     diagnostics.IllegalCharacter,
+	UnterminatedString:   diagnostics.UnterminatedString,
+	UnterminatedComment:  diagnostics.UnterminatedComment,
+	UnterminatedTemplate: diagnostics.UnterminatedTemplate,
+	MalformedNumber:      diagnostics.MalformedNumber,
+	InvalidEscape:        diagnostics.InvalidEscape,
+}
+
+// Returns the error as a [diagnostics.Diagnostic] spanning the offending
+// text, with the hint (if any) as a note.
+func (err Error) Diagnostic() *diagnostics.Diagnostic {
+	diagnostic := diagnostics.Errorf(errorCodes[err.Kind], diagnostics.SpanOf(err.Position, err.Text), "%s", err.Message)
+	if err.Hint != "" {
+		diagnostic.Notes = append(diagnostic.Notes, err.Hint)
+	}
+	return diagnostic
+}
+
+// Chars that are commonly typed by mistake, mapped to the hint shown when
+// one of them turns up as an illegal character.
+var illegalCharHints = map[rune]string{
+	0:    "NUL bytes cannot appear in source files; is this a binary file?",
+	'&':  "did you mean '&&'?",
+	'|':  "did you mean '||'?",
+	'#':  "did you mean '//'? comments start with '//'",
+	'\'': `did you mean '"'? strings use double quotes`,
+	'‘':  `did you mean '"'? strings use straight double quotes`,
+	'’':  `did you mean '"'? strings use straight double quotes`,
+	'“':  `did you mean '"'?`,
+	'”':  `did you mean '"'?`,
+	'≠':  "did you mean '!='?",
+	'≤':  "did you mean '<='?",
+	'≥':  "did you mean '>='?",
+	'−':  "did you mean '-'?",
+	'×':  "did you mean '*'?",
+	'÷':  "did you mean '/'?",
+}
+
+// Returns the errors the lexer has recorded so far, in source order.
+func (lex *Lexer) Errors() []Error {
+	return lex.errors
+}
+
+// Records an error of the given kind.
+func (lex *Lexer) addError(kind ErrorKind, position token.Position, text string, message string, hint string) {
+	lex.errors = append(lex.errors, Error{
+		Kind:     kind,
+		Position: position,
+		Text:     text,
+		Message:  message,
+		Hint:     hint,
+	})
+}
+
+// Records an [IllegalCharacter] error for text, the source text of a
+// single char that does not start any token.
+func (lex *Lexer) addIllegalCharError(position token.Position, text string) {
+	char, _ := utf8.DecodeRuneInString(text)
+
+	switch {
+	case char == utf8.RuneError && len(text) == 1:
+		lex.addError(IllegalCharacter, position, text,
+			fmt.Sprintf("invalid UTF-8 byte %#x", text[0]), "source files must be UTF-8 encoded")
+	case unicode.IsSpace(char):
+		lex.addError(IllegalCharacter, position, text,
+			fmt.Sprintf("illegal whitespace character %U", char), "did you mean ' '?")
+	default:
+		lex.addError(IllegalCharacter, position, text,
+			fmt.Sprintf("illegal character %q", char), illegalCharHints[char])
+	}
+}
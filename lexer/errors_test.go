@@ -0,0 +1,69 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+func TestErrors(t *testing.T) {
+	input := "let a = b & c;\nlet s = “hi”;\n\"bad \\q \\u{zz}\" 0xZZ 1.2.3 \xff\u00a0 \x00 @\n\"open"
+
+	expected := []Error{
+		{IllegalCharacter, token.Position{Line: 1, Column: 11, Offset: 10}, "&",
+			"illegal character '&'", "did you mean '&&'?"},
+		{IllegalCharacter, token.Position{Line: 2, Column: 9, Offset: 23}, "“",
+			"illegal character '“'", `did you mean '"'?`},
+		{IllegalCharacter, token.Position{Line: 2, Column: 12, Offset: 28}, "”",
+			"illegal character '”'", `did you mean '"'?`},
+		{InvalidEscape, token.Position{Line: 3, Column: 6, Offset: 38}, `\q`,
+			`unknown escape sequence \q`, `use "\\" for a literal backslash`},
+		{InvalidEscape, token.Position{Line: 3, Column: 9, Offset: 41}, `\u{`,
+			`invalid Unicode escape \u{`, `write \u{...} with 1 to 6 hex digits naming a valid code point`},
+		{MalformedNumber, token.Position{Line: 3, Column: 17, Offset: 49}, "0xZZ",
+			"invalid character 'Z' in hexadecimal literal", ""},
+		{MalformedNumber, token.Position{Line: 3, Column: 22, Offset: 54}, "1.2.3",
+			"number has more than one decimal point", ""},
+		{IllegalCharacter, token.Position{Line: 3, Column: 28, Offset: 60}, "\xff",
+			"invalid UTF-8 byte 0xff", "source files must be UTF-8 encoded"},
+		{IllegalCharacter, token.Position{Line: 3, Column: 29, Offset: 61}, "\u00a0",
+			"illegal whitespace character U+00A0", "did you mean ' '?"},
+		{IllegalCharacter, token.Position{Line: 3, Column: 31, Offset: 64}, "\x00",
+			`illegal character '\x00'`, "NUL bytes cannot appear in source files; is this a binary file?"},
+		{IllegalCharacter, token.Position{Line: 3, Column: 33, Offset: 66}, "@",
+			"illegal character '@'", ""},
+		{UnterminatedString, token.Position{Line: 4, Column: 1, Offset: 68}, `"open`,
+			"unterminated string literal", `add a closing '"'`},
+	}
+
+	testLexer := New(input)
+	for tok := testLexer.NextToken(); tok.Type != token.EOF; tok = testLexer.NextToken() {
+	}
+
+	errors := testLexer.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d: %v", len(expected), len(errors), errors)
+	}
+
+	for i, expectedError := range expected {
+		if errors[i] != expectedError {
+			t.Errorf("errors[%d] wrong.\nexpected=%#v\ngot=     %#v", i, expectedError, errors[i])
+		}
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	testLexer := New("x /* open", WithFileName("main.mk"))
+	for tok := testLexer.NextToken(); tok.Type != token.EOF; tok = testLexer.NextToken() {
+	}
+
+	errors := testLexer.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("wrong number of errors. expected=1, got=%d", len(errors))
+	}
+
+	expected := "main.mk:1:3: unterminated block comment (add a closing '*/')"
+	if errors[0].Error() != expected {
+		t.Errorf("Error() wrong. expected=%q, got=%q", expected, errors[0].Error())
+	}
+}
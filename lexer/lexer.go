@@ -1,24 +1,96 @@
 package lexer
 
-import "github.com/self-sasi/monkey-interpreter/token"
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
 
 // Represents a lexer that maintains state for lexical analysis.
 type Lexer struct {
-	input        string
-	position     int  // current position in input (points to the current char)
-	readPosition int  // current reading position in input (after current char)
-	char         byte // current char under examination
+	input        []byte // source text (only the buffered window of it when streaming)
+	offset       int    // byte offset in the source of input[0]
+	position     int    // current byte offset in the source (points to the current char)
+	readPosition int    // current reading offset in the source (after current char)
+	char         rune   // current char under examination, decoded from UTF-8
+
+	reader    io.Reader // source of further input when streaming, nil once drained
+	readError error     // first error other than io.EOF returned by reader
+	mark      int       // offset of the earliest text still needed; input before it can be dropped
+
+	errors    []Error          // lexical errors found so far
+	templates []*templateState // template strings being lexed, innermost last
+
+	fileName     string                     // name of the source file, recorded in token positions
+	keepComments bool                       // emit token.COMMENT tokens instead of skipping comments
+	operators    map[string]token.TokenType // operators added with WithOperator
+	line         int                        // line of the current char (1-based)
+	column       int                        // column of the current char in runes (1-based)
+}
+
+// Represents a configuration option that can be passed to [New].
+type Option func(*Lexer)
+
+// Returns an [Option] that makes the [Lexer] record fileName in the
+// [token.Position] of every token it emits.
+func WithFileName(fileName string) Option {
+	return func(lex *Lexer) {
+		lex.fileName = fileName
+	}
+}
+
+// Returns an [Option] that makes the [Lexer] emit a [token.COMMENT] token
+// for every comment instead of skipping it, so tools such as formatters can
+// keep comments attached to the code that follows them.
+func WithComments() Option {
+	return func(lex *Lexer) {
+		lex.keepComments = true
+	}
+}
+
+// Returns an [Option] that makes the [Lexer] recognise spelling as an
+// operator of type tokenType, on top of the operators of the language, so
+// that embedders can add operators of their own. As with the built-in
+// operators, the longest spelling matching the input wins.
+//
+// spelling must consist of 1 to 8 ASCII punctuation chars; WithOperator
+// panics otherwise.
+func WithOperator(spelling string, tokenType token.TokenType) Option {
+	if len(spelling) == 0 || len(spelling) > operatorLookahead {
+		panic(fmt.Sprintf("lexer: operator %q must be 1 to %d chars long", spelling, operatorLookahead))
+	}
+	for _, char := range spelling {
+		if char >= utf8.RuneSelf || !unicode.IsPunct(char) && !unicode.IsSymbol(char) {
+			panic(fmt.Sprintf("lexer: operator %q must consist of ASCII punctuation", spelling))
+		}
+	}
+
+	return func(lex *Lexer) {
+		if lex.operators == nil {
+			lex.operators = make(map[string]token.TokenType)
+		}
+		lex.operators[spelling] = tokenType
+	}
 }
 
 // Creates a new [Lexer] and returns the pointer to the struct.
-func New(input string) *Lexer {
-	newLexer := &Lexer{input: input}
+func New(input string, options ...Option) *Lexer {
+	newLexer := &Lexer{input: []byte(input), line: 1}
+	for _, option := range options {
+		option(newLexer)
+	}
 	newLexer.readChar()
 	return newLexer
 }
 
-// Makes the [Lexer] read a char, i.e, increment [Lexer.position] to the next
-// index and store the corresponding character in [Lexer.char].
+// Makes the [Lexer] read a char, i.e, advance [Lexer.position] to the next
+// UTF-8 encoded rune and store it in [Lexer.char]. Invalid encodings are
+// read as a single byte with [Lexer.char] set to [utf8.RuneError].
 //
 // For example:
 //
@@ -30,25 +102,58 @@ func New(input string) *Lexer {
 //	}
 //
 //	someLexer.readChar() // position == 1, char == 'y'
+//
+// With "héllo", reading past 'é' moves [Lexer.position] from 1 to 3 while
+// the column only advances by one.
+//
+// It also keeps [Lexer.line] and [Lexer.column] in sync with the new
+// [Lexer.position]. Once the end of input is reached, further calls leave
+// the lexer parked at len(input).
 func (lex *Lexer) readChar() {
-	if lex.readPosition >= len(lex.input) {
+	lex.fill(lex.readPosition + utf8.UTFMax)
+	end := lex.end()
+
+	if lex.char == '\n' {
+		lex.line += 1
+		lex.column = 1
+	} else if lex.readPosition <= end {
+		lex.column += 1
+	}
+
+	if lex.readPosition >= end {
 		lex.char = 0 // ascii code for "NUL" character
-	} else {
-		lex.char = lex.input[lex.readPosition]
+		lex.position = end
+		lex.readPosition = end + 1
+		return
 	}
+
+	char, size := utf8.DecodeRune(lex.input[lex.readPosition-lex.offset:])
+	lex.char = char
 	lex.position = lex.readPosition
-	lex.readPosition += 1
+	lex.readPosition += size
+}
+
+// Returns the [token.Position] of the current char.
+func (lex *Lexer) currentPosition() token.Position {
+	return token.Position{
+		File:   lex.fileName,
+		Line:   lex.line,
+		Column: lex.column,
+		Offset: lex.position,
+	}
 }
 
 // Makes the [Lexer] peek the next char, i.e, return the char that exists at
 // [Lexer.char]'s [Lexer.readPosition] index. This function comes in handy
 // when checking whether a token is single-character length, or double. For
 // example, differentiating between "=" and "==".
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	l.fill(l.readPosition + utf8.UTFMax)
+	if l.readPosition >= l.end() {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		char, _ := utf8.DecodeRune(l.input[l.readPosition-l.offset:])
+		return char
 	}
 }
 
@@ -58,114 +163,347 @@ func (l *Lexer) peekChar() byte {
 func (lex *Lexer) NextToken() token.Token {
 	var tok token.Token
 
-	lex.eatWhitespace() // clear any white space
+	if template := lex.currentTemplate(); template != nil && template.inText {
+		lex.mark = lex.position
+		return lex.readTemplateToken(template)
+	}
 
-	switch lex.char {
-	case '=':
-		if lex.peekChar() == '=' {
-			prevChar := lex.char
-			lex.readChar() // increment lex.position as it is a dual-char token
-			tok = newTwoCharToken(token.EQ, prevChar, lex.char)
-		} else {
-			tok = newToken(token.ASSIGN, lex.char)
-		}
-	case ';':
-		tok = newToken(token.SEMICOLON, lex.char)
-	case '(':
-		tok = newToken(token.LPAREN, lex.char)
-	case ')':
-		tok = newToken(token.RPAREN, lex.char)
-	case ',':
-		tok = newToken(token.COMMA, lex.char)
-	case '+':
-		tok = newToken(token.PLUS, lex.char)
-	case '{':
-		tok = newToken(token.LBRACE, lex.char)
-	case '}':
-		tok = newToken(token.RBRACE, lex.char)
-	case '!':
-		if lex.peekChar() == '=' {
-			prevChar := lex.char
-			lex.readChar() // increment lex.position as it is a dual-char token
-			tok = newTwoCharToken(token.NOT_EQ, prevChar, lex.char)
-		} else {
-			tok = newToken(token.BANG, lex.char)
-		}
-	case '-':
-		tok = newToken(token.MINUS, lex.char)
-	case '*':
-		tok = newToken(token.ASTERISK, lex.char)
-	case '/':
-		tok = newToken(token.SLASH, lex.char)
-	case '>':
-		tok = newToken(token.GT, lex.char)
-	case '<':
-		tok = newToken(token.LT, lex.char)
-	case 0:
+	// clear any white space and comments
+	for {
+		lex.eatWhitespace()
+		lex.mark = lex.position // nothing before the token or comment is needed
+		if !lex.atCommentStart() {
+			break
+		}
+
+		position := lex.currentPosition()
+		comment, terminated := lex.readComment()
+		if !terminated {
+			lex.addError(UnterminatedComment, position, comment,
+				"unterminated block comment", "add a closing '*/'")
+			return token.Token{Type: token.UNTERMINATED_COMMENT, Literal: comment, Position: position}
+		}
+		if lex.keepComments {
+			return token.Token{Type: token.COMMENT, Literal: comment, Position: position}
+		}
+	}
+	position := lex.currentPosition()
+
+	// the char reads as 0 past the end of the input, while a NUL in the
+	// input itself is an illegal char like any other
+	if lex.atEOF() {
+		if len(lex.templates) > 0 {
+			return lex.unterminatedTemplate()
+		}
 		tok.Literal = ""
 		tok.Type = token.EOF
+		tok.Position = position
+		return tok
+	}
+
+	switch lex.char {
+	case '`':
+		tok.Type = token.TEMPLATE_START
+		tok.Literal = "`"
+		lex.templates = append(lex.templates, &templateState{start: position, inText: true})
+	case '"':
+		start := lex.position
+		if value, terminated := lex.readString(); terminated {
+			tok.Type = token.STRING
+			tok.Literal = value
+		} else {
+			tok.Type = token.UNTERMINATED_STRING
+			tok.Literal = lex.text(start, lex.position)
+			lex.addError(UnterminatedString, position, tok.Literal,
+				"unterminated string literal", `add a closing '"'`)
+		}
 	default:
 		if isLetter(lex.char) {
 			tok.Literal = lex.readIdentifier()
 			tok.Type = token.LookupIdentifier(tok.Literal)
+			tok.Position = position
 			return tok
 		} else if isDigit(lex.char) {
-			tok.Type = token.INT
 			tok.Literal = lex.readNumber()
+			tokenType, problem := classifyNumber(tok.Literal)
+			if tokenType == token.MALFORMED_NUMBER {
+				lex.addError(MalformedNumber, position, tok.Literal, problem, "")
+			}
+			tok.Type = tokenType
+			tok.Position = position
 			return tok
+		} else if operatorType, length := lex.lookupOperator(); length > 0 {
+			tok.Type = lex.trackTemplateBraces(operatorType)
+			tok.Literal = lex.text(lex.position, lex.position+length)
+			for range length - 1 {
+				lex.readChar() // operators are ASCII, so every char is one byte
+			}
 		} else {
-			tok = newToken(token.ILLEGAL, lex.char)
+			tok.Type = token.ILLEGAL
+			tok.Literal = lex.currentText()
+			lex.addIllegalCharError(position, tok.Literal)
 		}
 	}
 
+	tok.Position = position
 	lex.readChar()
 	return tok
 }
 
-// Consumes consecutive letter characters starting at the current
-// [Lexer.position] and returns the corresponding identifier literal.
+// Consumes an identifier starting at the current [Lexer.position] and
+// returns the corresponding identifier literal. See [isLetter] and
+// [isIdentifierChar] for which chars make up an identifier.
 func (lex *Lexer) readIdentifier() string {
 	position := lex.position
-	for isLetter(lex.char) {
+	for isIdentifierChar(lex.char) {
 		lex.readChar()
 	}
-	return lex.input[position:lex.position]
+	return lex.text(position, lex.position)
 }
 
-// Consumes consecutive digit characters starting at the current
-// [Lexer.position] and returns the corresponding numeric literal.
+// Consumes a numeric literal starting at the current [Lexer.position] and
+// returns it as written. The scan is deliberately greedy: it takes every
+// identifier char, a '.' followed by a digit, and a sign directly after a
+// decimal exponent marker, so malformed numbers such as "1.2.3" or "0xZZ"
+// come back as one literal that [classifyNumber] can reject as a whole.
 func (lex *Lexer) readNumber() string {
 	position := lex.position
-	for isDigit(lex.char) {
+	isPrefixed := lex.char == '0' && isBasePrefix(lex.peekChar())
+
+	var prevChar rune
+	for {
+		switch {
+		case isDigit(lex.char) || isIdentifierChar(lex.char):
+		case lex.char == '.' && isDigit(lex.peekChar()):
+		case (lex.char == '+' || lex.char == '-') && (prevChar == 'e' || prevChar == 'E') &&
+			!isPrefixed && isDigit(lex.peekChar()):
+		default:
+			return lex.text(position, lex.position)
+		}
+		prevChar = lex.char
+		lex.readChar()
+	}
+}
+
+// Reports whether the current char starts a "//" or "/*" comment.
+func (lex *Lexer) atCommentStart() bool {
+	return lex.char == '/' && (lex.peekChar() == '/' || lex.peekChar() == '*')
+}
+
+// Consumes the comment starting at the current char and returns its text,
+// delimiters included. Line comments run up to (but not including) the next
+// newline. Block comments may be nested, i.e, "/* a /* b */ c */" is a
+// single comment. The second return value is false if the input ended
+// before a block comment was closed.
+//
+// Unless the lexer retains comments, only the opening "//" or "/*" is
+// returned, so that a streaming lexer can drop the text as it goes.
+//
+// Unlike token readers, this leaves the lexer on the char after the comment.
+func (lex *Lexer) readComment() (string, bool) {
+	start := lex.position
+	opening := lex.text(start, start+2)
+
+	// a skipped comment is not needed any more once read
+	release := func() {
+		if !lex.keepComments {
+			lex.mark = lex.position
+		}
+	}
+	text := func() string {
+		if lex.keepComments {
+			return lex.text(start, lex.position)
+		}
+		return opening
+	}
+
+	if lex.peekChar() == '/' {
+		for lex.char != '\n' && !lex.atEOF() {
+			release()
+			lex.readChar()
+		}
+		return text(), true
+	}
+
+	lex.readChar() // consume the opening "/*"
+	lex.readChar()
+	depth := 1
+
+	for depth > 0 {
+		release()
+		switch {
+		case lex.atEOF():
+			return text(), false
+		case lex.char == '/' && lex.peekChar() == '*':
+			depth += 1
+			lex.readChar()
+		case lex.char == '*' && lex.peekChar() == '/':
+			depth -= 1
+			lex.readChar()
+		}
+		lex.readChar()
+	}
+
+	return text(), true
+}
+
+// Consumes a double-quoted string literal starting at the opening quote and
+// returns its value with escape sequences resolved. On success the lexer is
+// left on the closing quote. The second return value is false if the input
+// ended before the closing quote was found.
+//
+// Supported escapes are \n, \t, \", \\, \u{...} (1 to 6 hex digits naming
+// a Unicode code point), and \` and \$ for template strings. Any other
+// backslash sequence is kept verbatim.
+func (lex *Lexer) readString() (string, bool) {
+	var out strings.Builder
+
+	for {
+		lex.readChar()
+
+		switch {
+		case lex.atEOF():
+			return out.String(), false
+		case lex.char == '"':
+			return out.String(), true
+		case lex.char == '\\':
+			position := lex.currentPosition()
+			lex.readChar()
+			lex.readEscape(&out, position)
+		default:
+			out.WriteString(lex.currentText())
+		}
+	}
+}
+
+// Resolves the escape sequence whose backslash, found at position, has just
+// been consumed and writes the result to out. The lexer is left on the last
+// char of the escape sequence.
+func (lex *Lexer) readEscape(out *strings.Builder, position token.Position) {
+	switch lex.char {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case '`':
+		out.WriteByte('`')
+	case '$':
+		out.WriteByte('$')
+	case 'u':
+		lex.readUnicodeEscape(out, position)
+	default:
+		out.WriteByte('\\')
+		out.WriteString(lex.currentText())
+		if !lex.atEOF() {
+			lex.addError(InvalidEscape, position, "\\"+lex.currentText(),
+				fmt.Sprintf("unknown escape sequence \\%s", lex.currentText()),
+				`use "\\" for a literal backslash`)
+		}
+	}
+}
+
+// Resolves a \u{...} escape whose 'u' is the current char and whose
+// backslash is at position, and writes the encoded code point to out.
+// Malformed escapes are written verbatim.
+func (lex *Lexer) readUnicodeEscape(out *strings.Builder, position token.Position) {
+	start := position.Offset
+	invalid := func() {
+		text := lex.text(start, lex.readPosition)
+		out.WriteString(text)
+		lex.addError(InvalidEscape, position, text,
+			fmt.Sprintf("invalid Unicode escape %s", text),
+			"write \\u{...} with 1 to 6 hex digits naming a valid code point")
+	}
+
+	if lex.peekChar() != '{' {
+		invalid()
+		return
+	}
+	lex.readChar()
+
+	digitsStart := lex.readPosition
+	for isHexDigit(lex.peekChar()) {
 		lex.readChar()
 	}
-	return lex.input[position:lex.position]
+	digits := lex.text(digitsStart, lex.readPosition)
+
+	if lex.peekChar() != '}' {
+		invalid()
+		return
+	}
+	lex.readChar()
+
+	codePoint, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(codePoint)) {
+		invalid()
+		return
+	}
+	out.WriteRune(rune(codePoint))
 }
 
-// Helper that creates a new token given the tokenType and ch (char).
-func newToken(tokenType token.TokenType, ch byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(ch)}
+// Returns the source text of the current char, which is empty once the
+// input has been consumed.
+func (lex *Lexer) currentText() string {
+	if lex.atEOF() {
+		return ""
+	}
+	return lex.text(lex.position, lex.readPosition)
 }
 
-// Helper that creates a new token with a two-char-length Literal value given the tokenType
-// and first and second characters.
-func newTwoCharToken(tokenType token.TokenType, firstCh byte, secondCh byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(firstCh) + string(secondCh)}
+// Reports whether the lexer has consumed all of its input.
+func (lex *Lexer) atEOF() bool {
+	return lex.position >= lex.end()
 }
 
-// Helper that determines if the given char is a letter.
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// Returns the [token.TokenType] and length of the longest operator that
+// starts at the current char, or [token.ILLEGAL] and 0 if there is none.
+func (lex *Lexer) lookupOperator() (token.TokenType, int) {
+	lex.fill(lex.position + operatorLookahead)
+	input := lex.text(lex.position, min(lex.position+operatorLookahead, lex.end()))
+
+	operatorType, length := token.LookupOperator(input)
+	for spelling, tokenType := range lex.operators {
+		if len(spelling) > length && strings.HasPrefix(input, spelling) {
+			operatorType, length = tokenType, len(spelling)
+		}
+	}
+	return operatorType, length
+}
+
+// Helper that determines if the given char can start an identifier, i.e,
+// it is '_' or a Unicode letter (general category L), such as 'a', 'é',
+// 'ж' or '名'.
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+// Helper that determines if the given char can continue an identifier,
+// i.e, it is accepted by [isLetter] or is a Unicode combining mark
+// (general category Mn or Mc), so scripts such as Devanagari or Tamil can
+// spell identifiers with vowel signs.
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || ch >= utf8.RuneSelf && unicode.In(ch, unicode.Mn, unicode.Mc)
 }
 
 // Helper that determines if the given char is a digit.
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// Helper that determines if the given char is a hexadecimal digit.
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
 // Helper that eats whitespace.
 func (lex *Lexer) eatWhitespace() {
 	for lex.char == ' ' || lex.char == '\t' || lex.char == '\n' || lex.char == '\r' {
+		lex.mark = lex.position
 		lex.readChar()
 	}
 }
@@ -16,10 +16,14 @@ func TestNextToken(t *testing.T) {
 	};
 
 	let result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 	true false if else return;
 	== !=;
+	"foobar"
+	"foo bar"
+	[1, 2];
+	{"foo": "bar"}
 	`
 
 	testCases := []struct {
@@ -83,6 +87,19 @@ func TestNextToken(t *testing.T) {
 		{token.EQ, "=="},
 		{token.NOT_EQ, "!="},
 		{token.SEMICOLON, ";"},
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
 
@@ -100,3 +117,542 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x == 10;\n"
+
+	testCases := []struct {
+		expectedType     token.TokenType
+		expectedPosition token.Position
+	}{
+		{token.LET, token.Position{File: "main.mk", Line: 1, Column: 1, Offset: 0}},
+		{token.IDENT, token.Position{File: "main.mk", Line: 1, Column: 5, Offset: 4}},
+		{token.ASSIGN, token.Position{File: "main.mk", Line: 1, Column: 7, Offset: 6}},
+		{token.INT, token.Position{File: "main.mk", Line: 1, Column: 9, Offset: 8}},
+		{token.SEMICOLON, token.Position{File: "main.mk", Line: 1, Column: 10, Offset: 9}},
+		{token.IDENT, token.Position{File: "main.mk", Line: 2, Column: 3, Offset: 13}},
+		{token.EQ, token.Position{File: "main.mk", Line: 2, Column: 5, Offset: 15}},
+		{token.INT, token.Position{File: "main.mk", Line: 2, Column: 8, Offset: 18}},
+		{token.SEMICOLON, token.Position{File: "main.mk", Line: 2, Column: 10, Offset: 20}},
+		{token.EOF, token.Position{File: "main.mk", Line: 3, Column: 1, Offset: 22}},
+		{token.EOF, token.Position{File: "main.mk", Line: 3, Column: 1, Offset: 22}},
+	}
+
+	testLexer := New(input, WithFileName("main.mk"))
+
+	for i, testCase := range testCases {
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Position != testCase.expectedPosition {
+			t.Fatalf("tests[%d] - position wrong. expected=%+v, got=%+v",
+				i, testCase.expectedPosition, tok.Position)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"Monkey"`, token.STRING, "Monkey"},
+		{`""`, token.STRING, ""},
+		{`"foo bar"`, token.STRING, "foo bar"},
+		{`"line\nbreak"`, token.STRING, "line\nbreak"},
+		{`"tab\tstop"`, token.STRING, "tab\tstop"},
+		{`"say \"hi\""`, token.STRING, `say "hi"`},
+		{`"back\\slash"`, token.STRING, `back\slash`},
+		{`"\u{48}\u{49}"`, token.STRING, "HI"},
+		{`"\u{1F600}"`, token.STRING, "\U0001F600"},
+		{`"\u{110000}"`, token.STRING, `\u{110000}`},
+		{`"\u{zz}"`, token.STRING, `\u{zz}`},
+		{`"\u41"`, token.STRING, `\u41`},
+		{`"\q"`, token.STRING, `\q`},
+		{`"multi
+line"`, token.STRING, "multi\nline"},
+		{`"abc`, token.UNTERMINATED_STRING, `"abc`},
+		{`"abc\"`, token.UNTERMINATED_STRING, `"abc\"`},
+		{`"abc\`, token.UNTERMINATED_STRING, `"abc\`},
+	}
+
+	for i, testCase := range testCases {
+		testLexer := New(testCase.input)
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+		if tok.Position.Offset != 0 || tok.Position.Line != 1 || tok.Position.Column != 1 {
+			t.Fatalf("tests[%d] - position wrong. got=%+v", i, tok.Position)
+		}
+		if next := testLexer.NextToken(); next.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after string, got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestUnicodeSource(t *testing.T) {
+	input := "let café = \"naïve 日本\";\nlet 名前 = नमस्ते + ж_1;\n\xff"
+
+	testCases := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedPosition token.Position
+	}{
+		{token.LET, "let", token.Position{Line: 1, Column: 1, Offset: 0}},
+		{token.IDENT, "café", token.Position{Line: 1, Column: 5, Offset: 4}},
+		{token.ASSIGN, "=", token.Position{Line: 1, Column: 10, Offset: 10}},
+		{token.STRING, "naïve 日本", token.Position{Line: 1, Column: 12, Offset: 12}},
+		{token.SEMICOLON, ";", token.Position{Line: 1, Column: 22, Offset: 27}},
+		{token.LET, "let", token.Position{Line: 2, Column: 1, Offset: 29}},
+		{token.IDENT, "名前", token.Position{Line: 2, Column: 5, Offset: 33}},
+		{token.ASSIGN, "=", token.Position{Line: 2, Column: 8, Offset: 40}},
+		{token.IDENT, "नमस्ते", token.Position{Line: 2, Column: 10, Offset: 42}},
+		{token.PLUS, "+", token.Position{Line: 2, Column: 17, Offset: 61}},
+		{token.IDENT, "ж_", token.Position{Line: 2, Column: 19, Offset: 63}},
+		{token.INT, "1", token.Position{Line: 2, Column: 21, Offset: 66}},
+		{token.SEMICOLON, ";", token.Position{Line: 2, Column: 22, Offset: 67}},
+		{token.ILLEGAL, "\xff", token.Position{Line: 3, Column: 1, Offset: 69}},
+		{token.EOF, "", token.Position{Line: 3, Column: 2, Offset: 70}},
+	}
+
+	testLexer := New(input)
+
+	for i, testCase := range testCases {
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+		if tok.Position != testCase.expectedPosition {
+			t.Fatalf("tests[%d] - position wrong. expected=%+v, got=%+v",
+				i, testCase.expectedPosition, tok.Position)
+		}
+	}
+}
+
+// Lexes the sample programs from monkey.md.
+func TestMonkeyDocSamples(t *testing.T) {
+	type expectedToken struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []expectedToken
+	}{
+		{
+			name: "variables and expressions",
+			input: `
+			let age = 1;
+			let name = "Monkey";
+			let result = 10 * (20 / 2);
+			`,
+			expected: []expectedToken{
+				{token.LET, "let"}, {token.IDENT, "age"}, {token.ASSIGN, "="},
+				{token.INT, "1"}, {token.SEMICOLON, ";"},
+				{token.LET, "let"}, {token.IDENT, "name"}, {token.ASSIGN, "="},
+				{token.STRING, "Monkey"}, {token.SEMICOLON, ";"},
+				{token.LET, "let"}, {token.IDENT, "result"}, {token.ASSIGN, "="},
+				{token.INT, "10"}, {token.ASTERISK, "*"}, {token.LPAREN, "("},
+				{token.INT, "20"}, {token.SLASH, "/"}, {token.INT, "2"},
+				{token.RPAREN, ")"}, {token.SEMICOLON, ";"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			name: "arrays and hash maps",
+			input: `
+			let myArray = [1, 2, 3, 4, 5];
+			let sasi = {"name": "SaSi", "age": 28};
+			myArray[0]
+			sasi["name"]
+			`,
+			expected: []expectedToken{
+				{token.LET, "let"}, {token.IDENT, "myArray"}, {token.ASSIGN, "="},
+				{token.LBRACKET, "["}, {token.INT, "1"}, {token.COMMA, ","},
+				{token.INT, "2"}, {token.COMMA, ","}, {token.INT, "3"},
+				{token.COMMA, ","}, {token.INT, "4"}, {token.COMMA, ","},
+				{token.INT, "5"}, {token.RBRACKET, "]"}, {token.SEMICOLON, ";"},
+				{token.LET, "let"}, {token.IDENT, "sasi"}, {token.ASSIGN, "="},
+				{token.LBRACE, "{"}, {token.STRING, "name"}, {token.COLON, ":"},
+				{token.STRING, "SaSi"}, {token.COMMA, ","}, {token.STRING, "age"},
+				{token.COLON, ":"}, {token.INT, "28"}, {token.RBRACE, "}"},
+				{token.SEMICOLON, ";"},
+				{token.IDENT, "myArray"}, {token.LBRACKET, "["}, {token.INT, "0"},
+				{token.RBRACKET, "]"},
+				{token.IDENT, "sasi"}, {token.LBRACKET, "["}, {token.STRING, "name"},
+				{token.RBRACKET, "]"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			name: "functions",
+			input: `
+			let add = fn(a, b) { return a + b; };
+			add(1, 2)
+			`,
+			expected: []expectedToken{
+				{token.LET, "let"}, {token.IDENT, "add"}, {token.ASSIGN, "="},
+				{token.FUNCTION, "fn"}, {token.LPAREN, "("}, {token.IDENT, "a"},
+				{token.COMMA, ","}, {token.IDENT, "b"}, {token.RPAREN, ")"},
+				{token.LBRACE, "{"}, {token.RETURN, "return"}, {token.IDENT, "a"},
+				{token.PLUS, "+"}, {token.IDENT, "b"}, {token.SEMICOLON, ";"},
+				{token.RBRACE, "}"}, {token.SEMICOLON, ";"},
+				{token.IDENT, "add"}, {token.LPAREN, "("}, {token.INT, "1"},
+				{token.COMMA, ","}, {token.INT, "2"}, {token.RPAREN, ")"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			name: "conditionals and recursion",
+			input: `
+			let fibonacci = fn(x) {
+				if (x == 0) {
+					0
+				} else {
+					if (x == 1) {
+						1
+					} else {
+						fibonacci(x - 1) + fibonacci(x - 2);
+					}
+				}
+			};
+			`,
+			expected: []expectedToken{
+				{token.LET, "let"}, {token.IDENT, "fibonacci"}, {token.ASSIGN, "="},
+				{token.FUNCTION, "fn"}, {token.LPAREN, "("}, {token.IDENT, "x"},
+				{token.RPAREN, ")"}, {token.LBRACE, "{"},
+				{token.IF, "if"}, {token.LPAREN, "("}, {token.IDENT, "x"},
+				{token.EQ, "=="}, {token.INT, "0"}, {token.RPAREN, ")"},
+				{token.LBRACE, "{"}, {token.INT, "0"}, {token.RBRACE, "}"},
+				{token.ELSE, "else"}, {token.LBRACE, "{"},
+				{token.IF, "if"}, {token.LPAREN, "("}, {token.IDENT, "x"},
+				{token.EQ, "=="}, {token.INT, "1"}, {token.RPAREN, ")"},
+				{token.LBRACE, "{"}, {token.INT, "1"}, {token.RBRACE, "}"},
+				{token.ELSE, "else"}, {token.LBRACE, "{"},
+				{token.IDENT, "fibonacci"}, {token.LPAREN, "("}, {token.IDENT, "x"},
+				{token.MINUS, "-"}, {token.INT, "1"}, {token.RPAREN, ")"},
+				{token.PLUS, "+"},
+				{token.IDENT, "fibonacci"}, {token.LPAREN, "("}, {token.IDENT, "x"},
+				{token.MINUS, "-"}, {token.INT, "2"}, {token.RPAREN, ")"},
+				{token.SEMICOLON, ";"},
+				{token.RBRACE, "}"}, {token.RBRACE, "}"}, {token.RBRACE, "}"},
+				{token.SEMICOLON, ";"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			name: "loops",
+			input: `
+			while (i < 10) { i += 1; }
+			for (let j = 0; j < 10; j += 1) { continue; }
+			for (name in names) { break; }
+			`,
+			expected: []expectedToken{
+				{token.WHILE, "while"}, {token.LPAREN, "("}, {token.IDENT, "i"},
+				{token.LT, "<"}, {token.INT, "10"}, {token.RPAREN, ")"},
+				{token.LBRACE, "{"}, {token.IDENT, "i"}, {token.PLUS_ASSIGN, "+="},
+				{token.INT, "1"}, {token.SEMICOLON, ";"}, {token.RBRACE, "}"},
+				{token.FOR, "for"}, {token.LPAREN, "("}, {token.LET, "let"},
+				{token.IDENT, "j"}, {token.ASSIGN, "="}, {token.INT, "0"},
+				{token.SEMICOLON, ";"}, {token.IDENT, "j"}, {token.LT, "<"},
+				{token.INT, "10"}, {token.SEMICOLON, ";"}, {token.IDENT, "j"},
+				{token.PLUS_ASSIGN, "+="}, {token.INT, "1"}, {token.RPAREN, ")"},
+				{token.LBRACE, "{"}, {token.CONTINUE, "continue"}, {token.SEMICOLON, ";"},
+				{token.RBRACE, "}"},
+				{token.FOR, "for"}, {token.LPAREN, "("}, {token.IDENT, "name"},
+				{token.IN, "in"}, {token.IDENT, "names"}, {token.RPAREN, ")"},
+				{token.LBRACE, "{"}, {token.BREAK, "break"}, {token.SEMICOLON, ";"},
+				{token.RBRACE, "}"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			name: "conditional and null-coalescing operators",
+			input: `
+			let sign = x > 0 ? 1 : -1;
+			let port = config["port"] ?? null;
+			`,
+			expected: []expectedToken{
+				{token.LET, "let"}, {token.IDENT, "sign"}, {token.ASSIGN, "="},
+				{token.IDENT, "x"}, {token.GT, ">"}, {token.INT, "0"},
+				{token.QUESTION, "?"}, {token.INT, "1"}, {token.COLON, ":"},
+				{token.MINUS, "-"}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+				{token.LET, "let"}, {token.IDENT, "port"}, {token.ASSIGN, "="},
+				{token.IDENT, "config"}, {token.LBRACKET, "["}, {token.STRING, "port"},
+				{token.RBRACKET, "]"}, {token.COALESCE, "??"}, {token.NULL, "null"},
+				{token.SEMICOLON, ";"},
+				{token.EOF, ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testLexer := New(tt.input)
+
+		for i, testCase := range tt.expected {
+			tok := testLexer.NextToken()
+			if tok.Type != testCase.expectedType {
+				t.Fatalf("%s: tests[%d] - tokentype wrong. expected=%q, got=%q",
+					tt.name, i, testCase.expectedType, tok.Type)
+			}
+			if tok.Literal != testCase.expectedLiteral {
+				t.Fatalf("%s: tests[%d] - literal wrong. expected=%q, got=%q",
+					tt.name, i, testCase.expectedLiteral, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	type expectedToken struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}
+
+	input := `// leading comment
+let x = 5; // trailing comment
+/* block /* nested */ still comment */ x / 2;
+/**/ x`
+
+	tests := []struct {
+		name     string
+		options  []Option
+		expected []expectedToken
+	}{
+		{
+			name: "skipped by default",
+			expected: []expectedToken{
+				{token.LET, "let"},
+				{token.IDENT, "x"},
+				{token.ASSIGN, "="},
+				{token.INT, "5"},
+				{token.SEMICOLON, ";"},
+				{token.IDENT, "x"},
+				{token.SLASH, "/"},
+				{token.INT, "2"},
+				{token.SEMICOLON, ";"},
+				{token.IDENT, "x"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			name:    "retained",
+			options: []Option{WithComments()},
+			expected: []expectedToken{
+				{token.COMMENT, "// leading comment"},
+				{token.LET, "let"},
+				{token.IDENT, "x"},
+				{token.ASSIGN, "="},
+				{token.INT, "5"},
+				{token.SEMICOLON, ";"},
+				{token.COMMENT, "// trailing comment"},
+				{token.COMMENT, "/* block /* nested */ still comment */"},
+				{token.IDENT, "x"},
+				{token.SLASH, "/"},
+				{token.INT, "2"},
+				{token.SEMICOLON, ";"},
+				{token.COMMENT, "/**/"},
+				{token.IDENT, "x"},
+				{token.EOF, ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testLexer := New(input, tt.options...)
+
+		for i, testCase := range tt.expected {
+			tok := testLexer.NextToken()
+			if tok.Type != testCase.expectedType {
+				t.Fatalf("%s: tests[%d] - tokentype wrong. expected=%q, got=%q",
+					tt.name, i, testCase.expectedType, tok.Type)
+			}
+			if tok.Literal != testCase.expectedLiteral {
+				t.Fatalf("%s: tests[%d] - literal wrong. expected=%q, got=%q",
+					tt.name, i, testCase.expectedLiteral, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	input := "x /* open /* nested */ still open"
+
+	tests := []struct {
+		options         []Option
+		expectedLiteral string
+	}{
+		// the text of a skipped comment is dropped as it is read
+		{nil, "/*"},
+		{[]Option{WithComments()}, "/* open /* nested */ still open"},
+	}
+
+	for i, tt := range tests {
+		testLexer := New(input, tt.options...)
+
+		if tok := testLexer.NextToken(); tok.Type != token.IDENT {
+			t.Fatalf("tests[%d] - first token wrong. expected=%q, got=%q", i, token.IDENT, tok.Type)
+		}
+
+		tok := testLexer.NextToken()
+		if tok.Type != token.UNTERMINATED_COMMENT {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, token.UNTERMINATED_COMMENT, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Position.Offset != 2 {
+			t.Fatalf("tests[%d] - position wrong. expected offset 2, got=%+v", i, tok.Position)
+		}
+
+		if tok := testLexer.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("tests[%d] - last token wrong. expected=%q, got=%q", i, token.EOF, tok.Type)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	input := `a <= b >= c && d || e % f;
+	x += 1; x -= 1; x *= 2; x /= 2; x %= 3;
+	<== !== &&& | |> ||> ? ?? ???`
+
+	testCases := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.OR, "||"},
+		{token.IDENT, "e"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "f"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.LT_EQ, "<="},
+		{token.ASSIGN, "="},
+		{token.NOT_EQ, "!="},
+		{token.ASSIGN, "="},
+		{token.AND, "&&"},
+		{token.ILLEGAL, "&"},
+		{token.ILLEGAL, "|"},
+		{token.PIPE, "|>"},
+		{token.OR, "||"},
+		{token.GT, ">"},
+		{token.QUESTION, "?"},
+		{token.COALESCE, "??"},
+		{token.COALESCE, "??"},
+		{token.QUESTION, "?"},
+		{token.EOF, ""},
+	}
+
+	testLexer := New(input)
+
+	for i, testCase := range testCases {
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestCustomOperators(t *testing.T) {
+	input := "a ** b *** c *= d ~ e <=> f ~~"
+
+	testCases := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{"POWER", "**"},
+		{token.IDENT, "b"},
+		{"POWER", "**"},
+		{token.ASTERISK, "*"},
+		{token.IDENT, "c"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.IDENT, "d"},
+		{"TILDE", "~"},
+		{token.IDENT, "e"},
+		{"SPACESHIP", "<=>"},
+		{token.IDENT, "f"},
+		{"TILDE", "~"},
+		{"TILDE", "~"},
+		{token.EOF, ""},
+	}
+
+	testLexer := New(input,
+		WithOperator("**", "POWER"),
+		WithOperator("~", "TILDE"),
+		WithOperator("<=>", "SPACESHIP"))
+
+	for i, testCase := range testCases {
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(testLexer.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", testLexer.Errors())
+	}
+
+	for _, spelling := range []string{"", "abc", "+1", "=>=>=>=>=", "→"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WithOperator(%q) did not panic", spelling)
+				}
+			}()
+			WithOperator(spelling, "BAD")
+		}()
+	}
+}
@@ -0,0 +1,111 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Represents the digits accepted after a base prefix such as "0x".
+type numberBase struct {
+	name    string
+	isDigit func(rune) bool
+}
+
+// The bases that can be selected with a prefix, keyed by the prefix letter.
+var numberBases = map[rune]numberBase{
+	'x': {"hexadecimal literal", isHexDigit},
+	'o': {"octal literal", func(ch rune) bool { return '0' <= ch && ch <= '7' }},
+	'b': {"binary literal", func(ch rune) bool { return ch == '0' || ch == '1' }},
+}
+
+// Helper that determines if the given char selects a base after a leading
+// '0', e.g, the 'x' in "0xFF".
+func isBasePrefix(ch rune) bool {
+	_, exists := numberBases[toLower(ch)]
+	return exists
+}
+
+// Classifies a literal consumed by [Lexer.readNumber]. It returns
+// [token.INT] for decimal, hexadecimal ("0xFF"), octal ("0o755") and
+// binary ("0b1010") integers, [token.FLOAT] for decimals with a fraction
+// and/or exponent ("3.14", "1e-9"), and [token.MALFORMED_NUMBER] together
+// with a description of the first problem found otherwise. Digits may be
+// separated by single underscores, as in "1_000_000".
+func classifyNumber(literal string) (token.TokenType, string) {
+	if len(literal) > 1 && literal[0] == '0' && isBasePrefix(rune(literal[1])) {
+		base := numberBases[toLower(rune(literal[1]))]
+		if problem := checkDigits(literal[2:], base.name, base.isDigit, true); problem != "" {
+			return token.MALFORMED_NUMBER, problem
+		}
+		return token.INT, ""
+	}
+
+	mantissa, exponent, hasExponent := cutExponent(literal)
+	whole, fraction, hasFraction := strings.Cut(mantissa, ".")
+
+	if strings.Contains(fraction, ".") {
+		return token.MALFORMED_NUMBER, "number has more than one decimal point"
+	}
+	if problem := checkDigits(whole, "number", isDigit, false); problem != "" {
+		return token.MALFORMED_NUMBER, problem
+	}
+	if hasFraction {
+		if problem := checkDigits(fraction, "fraction", isDigit, false); problem != "" {
+			return token.MALFORMED_NUMBER, problem
+		}
+	}
+	if hasExponent {
+		exponent = strings.TrimLeft(exponent, "+-")
+		if problem := checkDigits(exponent, "exponent", isDigit, false); problem != "" {
+			return token.MALFORMED_NUMBER, problem
+		}
+	}
+
+	if hasFraction || hasExponent {
+		return token.FLOAT, ""
+	}
+	return token.INT, ""
+}
+
+// Splits a decimal literal at its exponent marker ('e' or 'E'), if any.
+func cutExponent(literal string) (mantissa string, exponent string, found bool) {
+	if index := strings.IndexAny(literal, "eE"); index >= 0 {
+		return literal[:index], literal[index+1:], true
+	}
+	return literal, "", false
+}
+
+// Checks that digits is a non-empty run of chars accepted by isValid,
+// optionally separated by single underscores, and returns a description of
+// the first problem found, or "" if there is none. afterPrefix allows a
+// leading underscore, as in "0x_FF".
+func checkDigits(digits string, what string, isValid func(rune) bool, afterPrefix bool) string {
+	if digits == "" || digits == "_" && afterPrefix {
+		return fmt.Sprintf("%s has no digits", what)
+	}
+
+	var prevChar rune
+	for index, ch := range digits {
+		switch {
+		case ch == '_':
+			if prevChar == '_' || index == 0 && !afterPrefix {
+				return "'_' must separate successive digits"
+			}
+		case !isValid(ch):
+			return fmt.Sprintf("invalid character %q in %s", ch, what)
+		}
+		prevChar = ch
+	}
+
+	if prevChar == '_' {
+		return "'_' must separate successive digits"
+	}
+	return ""
+}
+
+// Helper that lower-cases an ASCII letter.
+func toLower(ch rune) rune {
+	return ch | ('a' - 'A')
+}
@@ -0,0 +1,120 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+func TestNumberLiterals(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"0", token.INT, "0"},
+		{"12345", token.INT, "12345"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0xFF", token.INT, "0xFF"},
+		{"0Xff", token.INT, "0Xff"},
+		{"0x_dead_beef", token.INT, "0x_dead_beef"},
+		{"0o755", token.INT, "0o755"},
+		{"0b1010", token.INT, "0b1010"},
+		{"3.14", token.FLOAT, "3.14"},
+		{"1e-9", token.FLOAT, "1e-9"},
+		{"1E+10", token.FLOAT, "1E+10"},
+		{"6.022e23", token.FLOAT, "6.022e23"},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1"},
+		{"1.2.3", token.MALFORMED_NUMBER, "1.2.3"},
+		{"0xZZ", token.MALFORMED_NUMBER, "0xZZ"},
+		{"0x", token.MALFORMED_NUMBER, "0x"},
+		{"0o789", token.MALFORMED_NUMBER, "0o789"},
+		{"0b102", token.MALFORMED_NUMBER, "0b102"},
+		{"0x1.5", token.MALFORMED_NUMBER, "0x1.5"},
+		{"1__000", token.MALFORMED_NUMBER, "1__000"},
+		{"1000_", token.MALFORMED_NUMBER, "1000_"},
+		{"1_.5", token.MALFORMED_NUMBER, "1_.5"},
+		{"1e", token.MALFORMED_NUMBER, "1e"},
+		{"12abc", token.MALFORMED_NUMBER, "12abc"},
+	}
+
+	for i, testCase := range testCases {
+		testLexer := New(testCase.input)
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+		if next := testLexer.NextToken(); next.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after number, got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestNumberBoundaries(t *testing.T) {
+	input := "1.foo 2-1 0x1e-2 5..6"
+
+	testCases := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "foo"},
+		{token.INT, "2"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.INT, "0x1e"},
+		{token.MINUS, "-"},
+		{token.INT, "2"},
+		{token.INT, "5"},
+		{token.ILLEGAL, "."},
+		{token.ILLEGAL, "."},
+		{token.INT, "6"},
+	}
+
+	testLexer := New(input)
+
+	for i, testCase := range testCases {
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestMalformedNumberProblems(t *testing.T) {
+	testCases := []struct {
+		literal         string
+		expectedProblem string
+	}{
+		{"1.2.3", "number has more than one decimal point"},
+		{"0xZZ", "invalid character 'Z' in hexadecimal literal"},
+		{"0b", "binary literal has no digits"},
+		{"0o8", "invalid character '8' in octal literal"},
+		{"1__0", "'_' must separate successive digits"},
+		{"1.5e", "exponent has no digits"},
+		{"12abc", "invalid character 'a' in number"},
+	}
+
+	for i, testCase := range testCases {
+		tokenType, problem := classifyNumber(testCase.literal)
+		if tokenType != token.MALFORMED_NUMBER {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, token.MALFORMED_NUMBER, tokenType)
+		}
+		if problem != testCase.expectedProblem {
+			t.Fatalf("tests[%d] - problem wrong. expected=%q, got=%q",
+				i, testCase.expectedProblem, problem)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"slices"
+)
+
+// The number of bytes a streaming [Lexer] asks its reader for at a time.
+const chunkSize = 4096
+
+// The number of bytes that must be buffered past the current char to match
+// an operator, comfortably more than the longest operator spelling.
+const operatorLookahead = 8
+
+// Creates a new [Lexer] that reads its input from reader instead of a
+// string and returns the pointer to the struct. The lexer produces the same
+// tokens as [New] would for the whole input, but only buffers the text it
+// still needs, i.e, the current token plus at most a chunk of lookahead, so
+// arbitrarily large scripts and pipes can be lexed in bounded memory.
+//
+// Errors returned by reader, other than [io.EOF], end the input early and
+// are reported by [Lexer.Err].
+func NewReader(reader io.Reader, options ...Option) *Lexer {
+	newLexer := &Lexer{
+		reader: reader,
+		line:   1,
+	}
+	for _, option := range options {
+		option(newLexer)
+	}
+	newLexer.readChar()
+	return newLexer
+}
+
+// Returns the first error other than [io.EOF] encountered while reading
+// the input of a [Lexer] created with [NewReader].
+func (lex *Lexer) Err() error {
+	return lex.readError
+}
+
+// Makes sure the input up to (but not including) the offset end is
+// buffered, reading from [Lexer.reader] as needed. Text before
+// [Lexer.mark] is dropped first, which is what keeps the buffer bounded.
+// The text still needed is moved to the front of the buffer, which only
+// grows when a token does not fit, and then in proportion to its size, so
+// reading a long token takes time linear in its length.
+// Does nothing for lexers created with [New].
+func (lex *Lexer) fill(end int) {
+	for lex.reader != nil && lex.end() < end {
+		if discard := lex.mark - lex.offset; discard > 0 {
+			kept := copy(lex.input, lex.input[discard:])
+			lex.input = lex.input[:kept]
+			lex.offset = lex.mark
+		}
+
+		buffered := len(lex.input)
+		lex.input = slices.Grow(lex.input, chunkSize)
+		n, err := lex.reader.Read(lex.input[buffered : buffered+chunkSize])
+		lex.input = lex.input[:buffered+n]
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				lex.readError = err
+			}
+			lex.reader = nil
+		}
+	}
+}
+
+// Returns the offset just past the last buffered byte of input.
+func (lex *Lexer) end() int {
+	return lex.offset + len(lex.input)
+}
+
+// Returns the source text between the offsets start and end, both of which
+// must still be buffered.
+func (lex *Lexer) text(start int, end int) string {
+	return string(lex.input[start-lex.offset : end-lex.offset])
+}
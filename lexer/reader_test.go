@@ -0,0 +1,156 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Source snippets that exercise every kind of token, combined at random by
+// [TestReaderMatchesString].
+var readerSnippets = []string{
+	"let", "fn", "if", "else", "return", "true", "false",
+	"x", "foobar", "café", "名前", "नमस्ते",
+	"0", "12345", "1_000", "0xFF", "0o755", "0b1010", "3.14", "1e-9", "1.2.3", "0xZZ",
+	`"str"`, `"esc\n\t\"\\"`, `"\u{1F600}"`, `"unterminated`,
+	"// line comment\n", "/* block /* nested */ */", "/* unterminated",
+	"=", "==", "!=", "<=", ">=", "&&", "||", "+=", "%=", "&", "|", "#", "\xff", "\x00",
+	"(", ")", "{", "}", "[", "]", ",", ";", ":",
+	"`", "`text ${", "\\`", "\\${", "$", "${",
+	" ", "\t", "\n", "\r\n",
+}
+
+// Lexes the whole input and returns every token up to and including EOF.
+func lexAll(lex *Lexer) []token.Token {
+	tokens := []token.Token{}
+	for {
+		tok := lex.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+func TestReaderMatchesString(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	inputs := []string{"", "   ", "let x = 5;", "let x = 1;\x00 let y = @;", "\x00"}
+	for range 200 {
+		var input strings.Builder
+		for range random.Intn(80) {
+			input.WriteString(readerSnippets[random.Intn(len(readerSnippets))])
+			if random.Intn(2) == 0 {
+				input.WriteByte(' ')
+			}
+		}
+		inputs = append(inputs, input.String())
+	}
+
+	readers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"plain", func(reader io.Reader) io.Reader { return reader }},
+		{"one byte", iotest.OneByteReader},
+		{"half", iotest.HalfReader},
+		{"data err", iotest.DataErrReader},
+	}
+
+	for i, input := range inputs {
+		expected := lexAll(New(input, WithFileName("f.mk"), WithComments()))
+
+		for _, reader := range readers {
+			streamLexer := NewReader(reader.wrap(strings.NewReader(input)),
+				WithFileName("f.mk"), WithComments())
+			got := lexAll(streamLexer)
+
+			if len(got) != len(expected) {
+				t.Fatalf("inputs[%d] %s - token count wrong. expected=%d, got=%d\ninput=%q",
+					i, reader.name, len(expected), len(got), input)
+			}
+			for j := range expected {
+				if got[j] != expected[j] {
+					t.Fatalf("inputs[%d] %s - tokens[%d] wrong. expected=%+v, got=%+v\ninput=%q",
+						i, reader.name, j, expected[j], got[j], input)
+				}
+			}
+			if streamLexer.Err() != nil {
+				t.Fatalf("inputs[%d] %s - unexpected error: %v", i, reader.name, streamLexer.Err())
+			}
+		}
+	}
+}
+
+// Repeats a snippet a fixed number of times without materialising the
+// whole text.
+type repeatReader struct {
+	snippet string
+	left    int
+	pending string
+}
+
+func (reader *repeatReader) Read(buffer []byte) (int, error) {
+	if reader.pending == "" {
+		if reader.left == 0 {
+			return 0, io.EOF
+		}
+		reader.pending = reader.snippet
+		reader.left -= 1
+	}
+	n := copy(buffer, reader.pending)
+	reader.pending = reader.pending[n:]
+	return n, nil
+}
+
+func TestReaderBufferIsBounded(t *testing.T) {
+	snippet := "let add = fn(x, y) { x + y; }; // sum\nadd(1_000, \"two\");\n"
+	repeats := 200_000 // about 12 MB of source
+
+	tests := []struct {
+		name          string
+		reader        io.Reader
+		expectedCount int
+	}{
+		{"code", &repeatReader{snippet: snippet, left: repeats}, (len(lexAll(New(snippet))) - 1) * repeats},
+		{"block comment", io.MultiReader(strings.NewReader("/*"),
+			&repeatReader{snippet: "text /* nested */ ", left: repeats}, strings.NewReader("*/ let x = 1;")), 5},
+		{"line comment", io.MultiReader(strings.NewReader("//"),
+			&repeatReader{snippet: "text of a long line ", left: repeats}, strings.NewReader("\nlet x = 1;")), 5},
+	}
+
+	for _, tt := range tests {
+		streamLexer := NewReader(tt.reader)
+
+		count := 0
+		for tok := streamLexer.NextToken(); tok.Type != token.EOF; tok = streamLexer.NextToken() {
+			count += 1
+			// the buffer only ever grows, so its capacity is the most it held
+			if cap(streamLexer.input) > 2*chunkSize {
+				t.Fatalf("%s: buffer grew to %d bytes after %d tokens", tt.name, cap(streamLexer.input), count)
+			}
+		}
+
+		if count != tt.expectedCount {
+			t.Fatalf("%s: token count wrong. expected=%d, got=%d", tt.name, tt.expectedCount, count)
+		}
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	readError := errors.New("disk on fire")
+	streamLexer := NewReader(io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(readError)))
+
+	tokens := lexAll(streamLexer)
+	if len(tokens) != 3 || tokens[0].Type != token.LET || tokens[1].Type != token.IDENT {
+		t.Fatalf("tokens wrong. got=%+v", tokens)
+	}
+	if !errors.Is(streamLexer.Err(), readError) {
+		t.Fatalf("Err() wrong. expected=%v, got=%v", readError, streamLexer.Err())
+	}
+}
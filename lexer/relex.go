@@ -0,0 +1,149 @@
+package lexer
+
+import "github.com/self-sasi/monkey-interpreter/token"
+
+// Represents a change to a source text: Removed bytes starting at the byte
+// offset Offset were replaced by Inserted.
+type Edit struct {
+	Offset   int
+	Removed  int
+	Inserted string
+}
+
+// Re-lexes a source text after an edit, reusing as much of its previous
+// token list as possible. previous must be the complete token list of the
+// text before the edit, as returned by [Lexer.NextToken] up to and
+// including [token.EOF], and input is the text after the edit. options
+// must match the ones previous was lexed with.
+//
+// Lexing restarts a couple of tokens before the edit and stops as soon as
+// it reaches a token that starts after the inserted text in the same lexer
+// state as one of the previous tokens did, from where on the previous
+// tokens are reused with their positions shifted. The result is always the
+// same as lexing input from scratch.
+func Relex(input string, previous []token.Token, edit Edit, options ...Option) []token.Token {
+	depths := templateDepths(previous)
+	restart := restartIndex(previous, depths, edit.Offset)
+
+	start := token.Position{Line: 1, Column: 1}
+	if restart > 0 {
+		start = previous[restart].Position
+	}
+	lex := newAt(input, start, options...)
+
+	delta := len(edit.Inserted) - edit.Removed
+	editEnd := edit.Offset + len(edit.Inserted) // in the new text
+	reusable := firstTokenAfter(previous, edit.Offset+edit.Removed)
+
+	tokens := append([]token.Token{}, previous[:restart]...)
+	for {
+		topLevel := len(lex.templates) == 0
+		tok := lex.NextToken()
+
+		if topLevel && tok.Position.Offset >= editEnd {
+			// skip previous tokens that start before tok would have
+			for reusable < len(previous) && previous[reusable].Position.Offset+delta < tok.Position.Offset {
+				reusable += 1
+			}
+			if reusable < len(previous) && depths[reusable] == 0 &&
+				isSameToken(previous[reusable], tok, delta) {
+				return append(tokens, shiftTokens(previous[reusable:], tok.Position)...)
+			}
+		}
+
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+// Creates a new [Lexer] over input that starts lexing at position instead
+// of at the beginning of input.
+func newAt(input string, position token.Position, options ...Option) *Lexer {
+	newLexer := &Lexer{
+		input:        []byte(input),
+		readPosition: position.Offset,
+		line:         position.Line,
+		column:       position.Column - 1,
+	}
+	for _, option := range options {
+		option(newLexer)
+	}
+	newLexer.readChar()
+	return newLexer
+}
+
+// Returns, for every token, the number of template strings that are open
+// just before it, i.e, whether the lexer was inside a template there.
+func templateDepths(tokens []token.Token) []int {
+	depths := make([]int, len(tokens))
+	depth := 0
+
+	for i, tok := range tokens {
+		depths[i] = depth
+
+		switch tok.Type {
+		case token.TEMPLATE_START:
+			depth += 1
+		case token.TEMPLATE_END:
+			depth -= 1
+		case token.UNTERMINATED_TEMPLATE:
+			depth = 0
+		}
+	}
+
+	return depths
+}
+
+// Returns the index of the token to restart lexing from for an edit at
+// offset. This is the second to last token starting before offset, since
+// deciding where a token ends can take up to two chars of lookahead (e.g,
+// "1" followed by ".5"), moved further back until it is outside of any
+// template string. Returns 0, i.e, lex from the very beginning, if there
+// is no such token.
+func restartIndex(tokens []token.Token, depths []int, offset int) int {
+	index := firstTokenAfter(tokens, offset) - 2
+	for index > 0 && depths[index] != 0 {
+		index -= 1
+	}
+	return max(index, 0)
+}
+
+// Returns the index of the first token starting at or after offset, or
+// len(tokens) if there is none.
+func firstTokenAfter(tokens []token.Token, offset int) int {
+	for i, tok := range tokens {
+		if tok.Position.Offset >= offset {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// Reports whether previous, moved by delta bytes, is the same token as
+// current.
+func isSameToken(previous token.Token, current token.Token, delta int) bool {
+	return previous.Type == current.Type &&
+		previous.Literal == current.Literal &&
+		previous.Position.Offset+delta == current.Position.Offset
+}
+
+// Returns a copy of tokens moved so that the first token starts at start.
+// Lines shift by the same amount for every token, columns only for tokens
+// on the same line as the first one.
+func shiftTokens(tokens []token.Token, start token.Position) []token.Token {
+	first := tokens[0].Position
+	shifted := make([]token.Token, len(tokens))
+
+	for i, tok := range tokens {
+		if tok.Position.Line == first.Line {
+			tok.Position.Column += start.Column - first.Column
+		}
+		tok.Position.Line += start.Line - first.Line
+		tok.Position.Offset += start.Offset - first.Offset
+		shifted[i] = tok
+	}
+
+	return shifted
+}
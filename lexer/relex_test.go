@@ -0,0 +1,121 @@
+package lexer
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Applies edit to input.
+func applyEdit(input string, edit Edit) string {
+	return input[:edit.Offset] + edit.Inserted + input[edit.Offset+edit.Removed:]
+}
+
+// Checks that relexing after edit gives the same tokens as a full lex.
+func testRelex(t *testing.T, input string, edit Edit, options ...Option) {
+	t.Helper()
+
+	previous := lexAll(New(input, options...))
+	edited := applyEdit(input, edit)
+
+	expected := lexAll(New(edited, options...))
+	got := Relex(edited, previous, edit, options...)
+
+	if len(got) != len(expected) {
+		t.Fatalf("token count wrong. expected=%d, got=%d\ninput=%q\nedit=%+v",
+			len(expected), len(got), input, edit)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("tokens[%d] wrong. expected=%+v, got=%+v\ninput=%q\nedit=%+v",
+				i, expected[i], got[i], input, edit)
+		}
+	}
+}
+
+func TestRelex(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		edit  Edit
+	}{
+		{"insert into identifier", "let foo = 5;", Edit{Offset: 7, Inserted: "bar"}},
+		{"append to identifier", "let foo = 5;", Edit{Offset: 4, Removed: 3, Inserted: "x"}},
+		{"join tokens", "a b", Edit{Offset: 1, Removed: 1}},
+		{"split token", "abc", Edit{Offset: 1, Inserted: " "}},
+		{"number becomes float", "x = 1.;", Edit{Offset: 6, Inserted: "5"}},
+		{"operator grows", "a < b", Edit{Offset: 3, Inserted: "="}},
+		{"open string", "let a = 1;\nlet b = 2;", Edit{Offset: 8, Inserted: `"`}},
+		{"close string", "let a = \"1;\nlet b = 2;", Edit{Offset: 10, Inserted: `"`}},
+		{"open comment", "a;\nb;\nc;", Edit{Offset: 3, Inserted: "/*"}},
+		{"edit in comment", "a; /* hi */ b;", Edit{Offset: 7, Removed: 2, Inserted: "*/ x /*"}},
+		{"edit in template", "`a ${b} c` + d;\ne;", Edit{Offset: 5, Removed: 1, Inserted: "x + `y`"}},
+		{"close template", "`a ${b} c + d;\ne;", Edit{Offset: 9, Inserted: "`"}},
+		{"new line", "let a = 1; let b = 2;\nlet c = 3;", Edit{Offset: 10, Inserted: "\n\n"}},
+		{"remove lines", "a;\nb;\nc;\nd;", Edit{Offset: 1, Removed: 5}},
+		{"unicode", "let café = \"naïve\"; x", Edit{Offset: 4, Removed: 5, Inserted: "名前"}},
+		{"at start", "let a = 1;", Edit{Offset: 0, Inserted: "x "}},
+		{"at end", "let a = 1;", Edit{Offset: 10, Inserted: " b"}},
+		{"everything", "let a = 1;", Edit{Offset: 0, Removed: 10, Inserted: "x"}},
+		{"empty", "", Edit{Offset: 0, Inserted: "let x = 1;"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testRelex(t, tt.input, tt.edit)
+			testRelex(t, tt.input, tt.edit, WithComments(), WithFileName("f.mk"))
+		})
+	}
+}
+
+func TestRelexRandomEdits(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+
+	randomText := func(snippets int) string {
+		var text strings.Builder
+		for range snippets {
+			text.WriteString(readerSnippets[random.Intn(len(readerSnippets))])
+			if random.Intn(2) == 0 {
+				text.WriteByte(' ')
+			}
+		}
+		return text.String()
+	}
+
+	for range 2000 {
+		input := randomText(random.Intn(40))
+
+		offset := random.Intn(len(input) + 1)
+		edit := Edit{
+			Offset:   offset,
+			Removed:  random.Intn(len(input) - offset + 1),
+			Inserted: randomText(random.Intn(3)),
+		}
+		if random.Intn(4) == 0 {
+			edit.Removed = min(edit.Removed, 1)
+		}
+
+		testRelex(t, input, edit, WithComments())
+	}
+}
+
+func TestRelexReusesTokens(t *testing.T) {
+	input := strings.Repeat("let x = 1;\n", 1000)
+	previous := lexAll(New(input))
+
+	edit := Edit{Offset: 5504, Removed: 1, Inserted: "yz"} // the x on line 501
+	edited := applyEdit(input, edit)
+
+	got := Relex(edited, previous, edit)
+	if got[2501].Literal != "yz" || got[2501].Type != token.IDENT {
+		t.Fatalf("edited token wrong. got=%+v", got[2501])
+	}
+	if got[4999].Position.Offset != previous[4999].Position.Offset+1 {
+		t.Fatalf("later tokens not shifted. got=%+v", got[4999].Position)
+	}
+	if restart := restartIndex(previous, templateDepths(previous), edit.Offset); restart != 2499 {
+		t.Fatalf("restart index wrong. expected=2499, got=%d", restart)
+	}
+}
@@ -0,0 +1,106 @@
+package lexer
+
+import (
+	"strings"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Represents a template string whose lexing is in progress. Template
+// strings nest, e.g, `a ${ `b ${c}` } d`, so the lexer keeps a stack of
+// them in [Lexer.templates].
+type templateState struct {
+	start  token.Position // position of the opening backtick
+	inText bool           // lexing literal text rather than an embedded expression
+	braces int            // unclosed '{' in the current embedded expression
+}
+
+// Returns the innermost template string being lexed, or nil if there is
+// none.
+func (lex *Lexer) currentTemplate() *templateState {
+	if len(lex.templates) == 0 {
+		return nil
+	}
+	return lex.templates[len(lex.templates)-1]
+}
+
+// Lexes the next token inside the literal text of template, i.e, one of
+// [token.TEMPLATE_TEXT], [token.TEMPLATE_EXPR_START] or
+// [token.TEMPLATE_END]. Unlike everywhere else, whitespace and comment
+// markers are part of the text here.
+func (lex *Lexer) readTemplateToken(template *templateState) token.Token {
+	position := lex.currentPosition()
+
+	switch {
+	case lex.atEOF():
+		return lex.unterminatedTemplate()
+	case lex.char == '`':
+		lex.templates = lex.templates[:len(lex.templates)-1]
+		lex.readChar()
+		return token.Token{Type: token.TEMPLATE_END, Literal: "`", Position: position}
+	case lex.char == '$' && lex.peekChar() == '{':
+		template.inText = false
+		template.braces = 0
+		lex.readChar()
+		lex.readChar()
+		return token.Token{Type: token.TEMPLATE_EXPR_START, Literal: "${", Position: position}
+	}
+
+	return token.Token{Type: token.TEMPLATE_TEXT, Literal: lex.readTemplateText(), Position: position}
+}
+
+// Consumes template text up to the next backtick, "${" or the end of input
+// and returns it with escape sequences resolved (see [Lexer.readString]).
+// This leaves the lexer on the char after the text.
+func (lex *Lexer) readTemplateText() string {
+	var out strings.Builder
+
+	for {
+		switch {
+		case lex.atEOF(), lex.char == '`', lex.char == '$' && lex.peekChar() == '{':
+			return out.String()
+		case lex.char == '\\':
+			position := lex.currentPosition()
+			lex.readChar()
+			lex.readEscape(&out, position)
+		default:
+			out.WriteString(lex.currentText())
+		}
+		lex.readChar()
+	}
+}
+
+// Keeps track of braces inside embedded template expressions, so that the
+// '}' matching a "${" is returned as [token.TEMPLATE_EXPR_END] and switches
+// the lexer back to template text. Returns the type to use for an operator
+// token of type operatorType.
+func (lex *Lexer) trackTemplateBraces(operatorType token.TokenType) token.TokenType {
+	template := lex.currentTemplate()
+	if template == nil {
+		return operatorType
+	}
+
+	switch operatorType {
+	case token.LBRACE:
+		template.braces += 1
+	case token.RBRACE:
+		if template.braces == 0 {
+			template.inText = true
+			return token.TEMPLATE_EXPR_END
+		}
+		template.braces -= 1
+	}
+	return operatorType
+}
+
+// Reports the outermost unfinished template string once the input has run
+// out, and returns the [token.UNTERMINATED_TEMPLATE] token for it. The
+// template stack is cleared, so the next token is [token.EOF].
+func (lex *Lexer) unterminatedTemplate() token.Token {
+	position := lex.templates[0].start
+	lex.templates = nil
+
+	lex.addError(UnterminatedTemplate, position, "`",
+		"unterminated template string", "add a closing '`'")
+	return token.Token{Type: token.UNTERMINATED_TEMPLATE, Literal: "`", Position: position}
+}
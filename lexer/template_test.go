@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+func TestTemplateStrings(t *testing.T) {
+	input := "`hello ${name}, you have ${ {\"n\": n}[\"n\"] } items`; `` `a\\`\\${b} ${ `in ${x}` }!`"
+
+	testCases := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_TEXT, "hello "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_TEXT, ", you have "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.LBRACE, "{"},
+		{token.STRING, "n"},
+		{token.COLON, ":"},
+		{token.IDENT, "n"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "n"},
+		{token.RBRACKET, "]"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_TEXT, " items"},
+		{token.TEMPLATE_END, "`"},
+		{token.SEMICOLON, ";"},
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_END, "`"},
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_TEXT, "a`${b} "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_TEXT, "in "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.IDENT, "x"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_END, "`"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_TEXT, "!"},
+		{token.TEMPLATE_END, "`"},
+		{token.EOF, ""},
+	}
+
+	testLexer := New(input)
+
+	for i, testCase := range testCases {
+		tok := testLexer.NextToken()
+		if tok.Type != testCase.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testCase.expectedType, tok.Type)
+		}
+		if tok.Literal != testCase.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testCase.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(testLexer.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", testLexer.Errors())
+	}
+}
+
+func TestUnterminatedTemplate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.TokenType
+	}{
+		{"x `abc", []token.TokenType{
+			token.IDENT, token.TEMPLATE_START, token.TEMPLATE_TEXT, token.UNTERMINATED_TEMPLATE, token.EOF,
+		}},
+		{"x `abc ${y", []token.TokenType{
+			token.IDENT, token.TEMPLATE_START, token.TEMPLATE_TEXT, token.TEMPLATE_EXPR_START,
+			token.IDENT, token.UNTERMINATED_TEMPLATE, token.EOF,
+		}},
+	}
+
+	for _, tt := range tests {
+		testLexer := New(tt.input)
+
+		for i, expectedType := range tt.expected {
+			tok := testLexer.NextToken()
+			if tok.Type != expectedType {
+				t.Fatalf("%q: tests[%d] - tokentype wrong. expected=%q, got=%q",
+					tt.input, i, expectedType, tok.Type)
+			}
+			if tok.Type == token.UNTERMINATED_TEMPLATE && tok.Position.Offset != 2 {
+				t.Fatalf("%q: position wrong. expected offset 2, got=%+v", tt.input, tok.Position)
+			}
+		}
+
+		errors := testLexer.Errors()
+		if len(errors) != 1 || errors[0].Kind != UnterminatedTemplate {
+			t.Fatalf("%q: errors wrong. got=%v", tt.input, errors)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package parser
+
+import "github.com/self-sasi/monkey-interpreter/diagnostics"
+
+// the nesting depth a [Parser] allows unless told otherwise with
+// [WithMaxDepth] or [Config.MaxDepth]
+const DefaultMaxDepth = 1000
+
+// Represents a set of optional language features, which can be switched
+// off with [Config.Disabled], e.g, to accept pure expressions only.
+type Feature uint
+
+// Optional language features
+const (
+	Assignments     Feature = 1 << iota // x = y and compound assignments like x += y
+	Loops                               // while, for and for-in loops with break and continue
+	TemplateStrings                     // `hello ${name}`
+)
+
+// name of every feature, as shown in diagnostics
+var featureNames = map[Feature]string{
+	Assignments:     "assignments",
+	Loops:           "loops",
+	TemplateStrings: "template strings",
+}
+
+// Represents the settings of a [Parser]. The zero value is a valid
+// configuration that enables every feature.
+type Config struct {
+	FileName string  // recorded in every token and diagnostic, if not empty
+	MaxDepth int     // how deeply expressions and blocks may nest; DefaultMaxDepth if 0
+	Disabled Feature // features the parser rejects with a diagnostic
+}
+
+// Represents a setting of a [Parser], passed to [New].
+type Option func(*Parser)
+
+// Returns an [Option] that makes the [Parser] use config, replacing the
+// settings made by earlier options.
+func WithConfig(config Config) Option {
+	return func(parser *Parser) {
+		parser.config = config
+	}
+}
+
+// Returns an [Option] that limits how deeply expressions and blocks may
+// nest, so that hostile input such as 100k opening parentheses yields a
+// diagnostic instead of exhausting the stack. The default is
+// [DefaultMaxDepth].
+func WithMaxDepth(maxDepth int) Option {
+	return func(parser *Parser) {
+		parser.config.MaxDepth = maxDepth
+	}
+}
+
+// helper that reports whether feature is enabled. if it is not, it
+// records an error at the current token, which starts the construct that
+// needs the feature.
+func (parser *Parser) requireFeature(feature Feature) bool {
+	if parser.config.Disabled&feature == 0 {
+		return true
+	}
+
+	parser.addError(diagnostics.Errorf(diagnostics.FeatureDisabled, tokenSpan(parser.curToken),
+		"%s are disabled", featureNames[feature]))
+	return false
+}
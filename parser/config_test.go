@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
+	"github.com/self-sasi/monkey-interpreter/lexer"
+)
+
+func TestDisabledFeatures(t *testing.T) {
+	tests := []struct {
+		input         string
+		disabled      Feature
+		expectedError string
+	}{
+		{"x = 1;", Assignments, "1:3: assignments are disabled"},
+		{"arr[0] += 1;", Assignments, "1:8: assignments are disabled"},
+		{"while (x) {}", Loops, "1:1: loops are disabled"},
+		{"for (;;) {}", Loops, "1:1: loops are disabled"},
+		{"for (x in xs) {}", Loops | Assignments, "1:1: loops are disabled"},
+		{"let s = `a${b}`;", TemplateStrings, "1:9: template strings are disabled"},
+		{"x = 1;", Loops | TemplateStrings, ""},
+		{"let s = `a`; for (;;) { break; }", Assignments, ""},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l, WithConfig(Config{Disabled: tt.disabled}))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if tt.expectedError == "" {
+			if len(errors) != 0 {
+				t.Errorf("tests[%d] - unexpected errors: %q", i, errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+		if errors[0].Code != diagnostics.FeatureDisabled {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q", i, diagnostics.FeatureDisabled, errors[0].Code)
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func TestConfigFileName(t *testing.T) {
+	l := lexer.New("let x 1;\nlet y = #;")
+	p := New(l, WithConfig(Config{FileName: "main.mk"}))
+	program := p.ParseProgram()
+
+	expected := []string{
+		"main.mk:1:7: expected next token to be =, got INT instead",
+		"main.mk:2:9: illegal character '#'",
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%q)", len(expected), len(errors), errors)
+	}
+	for i, want := range expected {
+		if errors[i].Error() != want {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, want, errors[i].Error())
+		}
+	}
+
+	if len(program.Statements) != 0 {
+		t.Fatalf("expected no statements, got=%q", program.String())
+	}
+}
+
+func TestConfigMaxDepth(t *testing.T) {
+	tests := []struct {
+		options        []Option
+		expectedErrors int
+	}{
+		{nil, 0},
+		{[]Option{WithConfig(Config{})}, 0},
+		{[]Option{WithConfig(Config{MaxDepth: 2})}, 1},
+		{[]Option{WithMaxDepth(2)}, 1},
+		{[]Option{WithMaxDepth(2), WithConfig(Config{FileName: "main.mk"})}, 0},
+		{[]Option{WithConfig(Config{FileName: "main.mk"}), WithMaxDepth(2)}, 1},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New("(((1)))")
+		p := New(l, tt.options...)
+		p.ParseProgram()
+
+		if len(p.Errors()) != tt.expectedErrors {
+			t.Errorf("tests[%d] - wrong number of errors. expected=%d, got=%d (%q)",
+				i, tt.expectedErrors, len(p.Errors()), p.Errors())
+		}
+	}
+}
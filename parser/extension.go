@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"github.com/self-sasi/monkey-interpreter/ast"
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+// Represents a function parsing an expression that starts with a token
+// registered through [Parser.RegisterPrefix]. It is called with that token
+// as the current token, and must leave the parser on the last token of the
+// expression. If there is no valid expression, it returns nil after
+// recording an error with [Parser.AddError]; a nil result without an error
+// is reported as a missing expression.
+type PrefixParseFunc func(parser *Parser) ast.Expression
+
+// Represents a function parsing an infix expression for an operator
+// registered through [Parser.RegisterInfix]. It is called with the operator
+// as the current token and the already parsed left operand, and must leave
+// the parser on the last token of the expression. Like a [PrefixParseFunc],
+// it returns nil only after recording an error.
+type InfixParseFunc func(parser *Parser, left ast.Expression) ast.Expression
+
+// Makes the parser call fn for expressions starting with tokenType,
+// replacing the built-in parse function if there is one. Operators the
+// language does not know yet need a lexer set up with
+// [lexer.WithOperator] as well.
+func (parser *Parser) RegisterPrefix(tokenType token.TokenType, fn PrefixParseFunc) {
+	parser.registerPrefix(tokenType, func() ast.Expression {
+		return fn(parser)
+	})
+}
+
+// Makes the parser call fn for tokenType in infix position, binding with
+// the given precedence, e.g, [SUM] for an operator that binds like +.
+// A left-associative operator parses its right operand with
+// ParseExpression(precedence), a right-associative one with
+// ParseExpression(precedence - 1).
+func (parser *Parser) RegisterInfix(tokenType token.TokenType, precedence int, fn InfixParseFunc) {
+	parser.precedences[tokenType] = precedence
+	parser.registerInfix(tokenType, func(left ast.Expression) ast.Expression {
+		return fn(parser, left)
+	})
+}
+
+// Returns the token under examination.
+func (parser *Parser) CurToken() token.Token {
+	return parser.curToken
+}
+
+// Returns the token after the current one.
+func (parser *Parser) PeekToken() token.Token {
+	return parser.peekToken
+}
+
+// Advances the parser to the next token.
+func (parser *Parser) NextToken() {
+	parser.nextToken()
+}
+
+// Advances the parser to the next token if it has type tokenType, and
+// reports whether it did. If it does not, an error is recorded.
+func (parser *Parser) ExpectPeek(tokenType token.TokenType) bool {
+	return parser.expectPeek(tokenType)
+}
+
+// Parses the expression starting at the current token, folding in infix
+// operators for as long as they bind tighter than precedence. Returns nil
+// after recording an error if there is no valid expression.
+func (parser *Parser) ParseExpression(precedence int) ast.Expression {
+	return parser.parseExpression(precedence)
+}
+
+// Records diagnostic as a problem in the source. Like the parser's own
+// errors, it makes the parser skip to the next statement, and errors
+// recorded before getting there are dropped.
+func (parser *Parser) AddError(diagnostic *diagnostics.Diagnostic) {
+	parser.addError(diagnostic)
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/self-sasi/monkey-interpreter/ast"
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
+	"github.com/self-sasi/monkey-interpreter/lexer"
+	"github.com/self-sasi/monkey-interpreter/token"
+)
+
+const (
+	POWER   = "POWER"
+	TILDE   = "TILDE"
+	NOTHING = "NOTHING"
+)
+
+// parses a ** b as a right-associative binary operator, using the
+// exported API only
+func parsePower(parser *Parser, left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    parser.CurToken(),
+		Operator: parser.CurToken().Literal,
+		Left:     left,
+	}
+
+	parser.NextToken()
+	expression.Right = parser.ParseExpression(PRODUCT - 1)
+
+	return expression
+}
+
+// parses ~x, rejecting anything but identifiers
+func parseTilde(parser *Parser) ast.Expression {
+	expression := &ast.PrefixExpression{Token: parser.CurToken(), Operator: "~"}
+
+	if !parser.ExpectPeek(token.IDENT) {
+		return nil
+	}
+	expression.Right = &ast.Identifier{Token: parser.CurToken(), Value: parser.CurToken().Literal}
+
+	return expression
+}
+
+// a broken parse function for @@, which returns no expression without
+// recording an error
+func parseNothing(parser *Parser) ast.Expression {
+	return nil
+}
+
+func newExtendedParser(input string) *Parser {
+	l := lexer.New(input, lexer.WithOperator("**", POWER), lexer.WithOperator("~", TILDE),
+		lexer.WithOperator("@@", NOTHING))
+	p := New(l)
+	p.RegisterInfix(POWER, PRODUCT, parsePower)
+	p.RegisterPrefix(TILDE, parseTilde)
+	p.RegisterPrefix(NOTHING, parseNothing)
+	return p
+}
+
+func TestRegisteredOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 ** 3", "(2 ** 3)"},
+		{"2 ** 3 ** 2", "(2 ** (3 ** 2))"},
+		{"1 + 2 ** 3", "(1 + (2 ** 3))"},
+		{"2 ** 3 + 1", "((2 ** 3) + 1)"},
+		{"-2 ** 2", "((-2) ** 2)"},
+		{"~x + 1", "((~x) + 1)"},
+		{"f(~a, b ** ~c)", "f((~a), (b ** (~c)))"},
+		{"let x = ~y ** 2;", "let x = ((~y) ** 2);"},
+	}
+
+	for i, tt := range tests {
+		p := newExtendedParser(tt.input)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, program.String())
+		}
+	}
+}
+
+func TestRegisteredOperatorErrors(t *testing.T) {
+	p := newExtendedParser("~1; let y = 2 **; let x = @@ + 1; let z = 3;")
+	program := p.ParseProgram()
+
+	expected := []string{
+		"1:2: expected next token to be IDENT, got INT instead",
+		"1:17: expected an expression, got ; instead",
+		"1:27: expected an expression, got no valid one starting with @@",
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%q)", len(expected), len(errors), errors)
+	}
+	for i, want := range expected {
+		if errors[i].Error() != want {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, want, errors[i].Error())
+		}
+	}
+
+	if program.String() != "let z = 3;" {
+		t.Errorf("recovered program wrong. got=%q", program.String())
+	}
+}
+
+func TestAddError(t *testing.T) {
+	l := lexer.New("let x = 1; x; let y = 2;")
+	p := New(l)
+
+	// a prefix function that rejects a construct the language allows
+	p.RegisterPrefix(token.INT, func(parser *Parser) ast.Expression {
+		if parser.CurToken().Literal == "1" {
+			parser.AddError(diagnostics.Errorf("X0001", diagnostics.SpanOf(parser.CurToken().Position, "1"),
+				"one is not allowed"))
+			return nil
+		}
+		return &ast.IntegerLiteral{Token: parser.CurToken(), Value: 2}
+	})
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 1 || p.Errors()[0].Code != "X0001" {
+		t.Fatalf("errors wrong. got=%q", p.Errors())
+	}
+
+	if program.String() != "xlet y = 2;" {
+		t.Errorf("recovered program wrong. got=%q", program.String())
+	}
+}
@@ -2,8 +2,12 @@ package parser
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/self-sasi/monkey-interpreter/ast"
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
 	"github.com/self-sasi/monkey-interpreter/lexer"
 	"github.com/self-sasi/monkey-interpreter/token"
 )
@@ -12,14 +16,49 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = y or x += y
+	TERNARY     // c ? a : b
+	COALESCE    // a ?? b
+	OR          // ||
+	AND         // &&
+	PIPE        // x |> f(y)
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
+// precedence of every built-in infix operator
+var precedences = map[token.TokenType]int{
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.PERCENT_ASSIGN:  ASSIGN,
+	token.QUESTION:        TERNARY,
+	token.COALESCE:        COALESCE,
+	token.OR:              OR,
+	token.AND:             AND,
+	token.PIPE:            PIPE,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.ASTERISK:        PRODUCT,
+	token.SLASH:           PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+}
+
 type (
 	prefixParseFunction func() ast.Expression
 	infixParseFunction  func(ast.Expression) ast.Expression
@@ -28,20 +67,39 @@ type (
 // Parser transforms a stream of tokens produced by the lexer
 // into an Abstract Syntax Tree (AST).
 type Parser struct {
-	lex       *lexer.Lexer // source of tokens
-	curToken  token.Token  // current token under examination
-	peekToken token.Token  // next token (one-token lookahead)
-	errors    []string     // list of errors
+	lex       *lexer.Lexer              // source of tokens
+	curToken  token.Token               // current token under examination
+	peekToken token.Token               // next token (one-token lookahead)
+	errors    []*diagnostics.Diagnostic // problems found while parsing
+	comments  []token.Token             // comments skipped so far, in source order
 
+	panicking       bool          // set after an error until the parser resynchronizes
+	afterExpression bool          // whether curToken is the last token of an expression
+	loops           int           // number of loops enclosing curToken within the current function
+	braces          int           // number of { consumed but not yet closed
+	parens          int           // number of ( and [ consumed but not yet closed
+	depth           int           // number of expressions and blocks being parsed
+	tooDeep         bool          // whether the statement being parsed exceeded config.MaxDepth
+	blocks          []token.Token // the { of every block being parsed, innermost last
+	unclosedBlocks  bool          // whether blocks left open at EOF were reported
+
+	config         Config
+	precedences    map[token.TokenType]int // precedences, including those of registered operators
 	prefixParseFns map[token.TokenType]prefixParseFunction
 	infixParseFns  map[token.TokenType]infixParseFunction
 }
 
 // Creates and initializes a new Parser.
-func New(lex *lexer.Lexer) *Parser {
+func New(lex *lexer.Lexer, options ...Option) *Parser {
 	parserPointer := &Parser{
 		lex:    lex,
-		errors: []string{},
+		errors: []*diagnostics.Diagnostic{},
+	}
+	for _, option := range options {
+		option(parserPointer)
+	}
+	if parserPointer.config.MaxDepth <= 0 {
+		parserPointer.config.MaxDepth = DefaultMaxDepth
 	}
 
 	// read two tokens, so curToken and peekToken are both set
@@ -50,6 +108,37 @@ func New(lex *lexer.Lexer) *Parser {
 
 	parserPointer.prefixParseFns = make(map[token.TokenType]prefixParseFunction)
 	parserPointer.registerPrefix(token.IDENT, parserPointer.parseIdentifier)
+	parserPointer.registerPrefix(token.INT, parserPointer.parseIntegerLiteral)
+	parserPointer.registerPrefix(token.FLOAT, parserPointer.parseFloatLiteral)
+	parserPointer.registerPrefix(token.STRING, parserPointer.parseStringLiteral)
+	parserPointer.registerPrefix(token.TRUE, parserPointer.parseBoolean)
+	parserPointer.registerPrefix(token.FALSE, parserPointer.parseBoolean)
+	parserPointer.registerPrefix(token.NULL, parserPointer.parseNullLiteral)
+	parserPointer.registerPrefix(token.TEMPLATE_START, parserPointer.parseTemplateLiteral)
+	parserPointer.registerPrefix(token.BANG, parserPointer.parsePrefixExpression)
+	parserPointer.registerPrefix(token.MINUS, parserPointer.parsePrefixExpression)
+	parserPointer.registerPrefix(token.LPAREN, parserPointer.parseGroupedExpression)
+	parserPointer.registerPrefix(token.IF, parserPointer.parseIfExpression)
+	parserPointer.registerPrefix(token.FUNCTION, parserPointer.parseFunctionLiteral)
+
+	parserPointer.registerPrefix(token.LBRACKET, parserPointer.parseArrayLiteral)
+	parserPointer.registerPrefix(token.LBRACE, parserPointer.parseHashLiteral)
+
+	parserPointer.precedences = make(map[token.TokenType]int)
+	parserPointer.infixParseFns = make(map[token.TokenType]infixParseFunction)
+	for tokenType, precedence := range precedences {
+		parserPointer.precedences[tokenType] = precedence
+		parserPointer.registerInfix(tokenType, parserPointer.parseInfixExpression)
+	}
+	parserPointer.registerInfix(token.LPAREN, parserPointer.parseCallExpression)
+	parserPointer.registerInfix(token.PIPE, parserPointer.parsePipeExpression)
+	parserPointer.registerInfix(token.QUESTION, parserPointer.parseConditionalExpression)
+	parserPointer.registerInfix(token.COALESCE, parserPointer.parseCoalesceExpression)
+	for _, tokenType := range []token.TokenType{token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN,
+		token.ASTERISK_ASSIGN, token.SLASH_ASSIGN, token.PERCENT_ASSIGN} {
+		parserPointer.registerInfix(tokenType, parserPointer.parseAssignExpression)
+	}
+	parserPointer.registerInfix(token.LBRACKET, parserPointer.parseIndexExpression)
 
 	return parserPointer
 }
@@ -57,20 +146,272 @@ func New(lex *lexer.Lexer) *Parser {
 // Advances the parser to the next token.
 // The current token becomes the previous peek token,
 // and a new peek token is read from the lexer.
+// Comments, which the lexer only emits when asked to retain them, are
+// set aside for [ast.Program.Comments] rather than parsed.
 func (parser *Parser) nextToken() {
 	parser.curToken = parser.peekToken
-	parser.peekToken = parser.lex.NextToken()
+	parser.peekToken = parser.readToken()
+	for parser.peekToken.Type == token.COMMENT {
+		parser.comments = append(parser.comments, parser.peekToken)
+		parser.peekToken = parser.readToken()
+	}
+	parser.afterExpression = false
+
+	switch parser.curToken.Type {
+	case token.LBRACE:
+		parser.braces++
+	case token.RBRACE:
+		// a stray } must not make every later brace look unbalanced
+		if parser.braces > 0 {
+			parser.braces--
+		}
+	case token.LPAREN, token.LBRACKET:
+		parser.parens++
+	case token.RPAREN, token.RBRACKET:
+		if parser.parens > 0 {
+			parser.parens--
+		}
+	}
+}
+
+// helper that reads the next token from the lexer, placing it in the
+// configured file.
+func (parser *Parser) readToken() token.Token {
+	tok := parser.lex.NextToken()
+	if parser.config.FileName != "" {
+		tok.Position.File = parser.config.FileName
+	}
+	return tok
+}
+
+// returns the problems found in the source so far, both by the lexer and
+// by the parser, in source order.
+func (parser *Parser) Errors() []*diagnostics.Diagnostic {
+	errors := []*diagnostics.Diagnostic{}
+	for _, err := range parser.lex.Errors() {
+		diagnostic := err.Diagnostic()
+		if parser.config.FileName != "" {
+			diagnostic.Span.Start.File = parser.config.FileName
+			diagnostic.Span.End.File = parser.config.FileName
+		}
+		errors = append(errors, diagnostic)
+	}
+	errors = append(errors, parser.errors...)
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Span.Start.Offset < errors[j].Span.Start.Offset
+	})
+	return errors
+}
+
+// records that the next token is none of the expected token types. when
+// the current token ends an expression, any infix operator could have
+// continued it, so those are listed as valid as well.
+func (parser *Parser) peekError(expected ...token.TokenType) {
+	if isLexerError(parser.peekToken.Type) {
+		// the lexer has reported this one already
+		parser.panicking = true
+		return
+	}
+
+	names := make([]string, len(expected))
+	for i, tokenType := range expected {
+		names[i] = string(tokenType)
+	}
+
+	diagnostic := diagnostics.Errorf(diagnostics.UnexpectedToken, tokenSpan(parser.peekToken),
+		"expected next token to be %s, got %s instead", strings.Join(names, " or "), parser.peekToken.Type)
+
+	if parser.afterExpression {
+		valid := append(append([]token.TokenType{}, expected...), sortedTokenTypes(parser.infixParseFns)...)
+		diagnostic.Notes = append(diagnostic.Notes, "expected one of "+quoteTokenTypes(valid))
+	}
+
+	// a single missing delimiter or operator can be suggested as an
+	// insertion right after the current token
+	if len(expected) == 1 {
+		if tokenType, length := token.LookupOperator(string(expected[0])); tokenType == expected[0] && length == len(expected[0]) {
+			end := tokenSpan(parser.curToken).End
+			diagnostic.Fix = &diagnostics.Fix{
+				Message:     fmt.Sprintf("insert %q", expected[0]),
+				Span:        diagnostics.Span{Start: end, End: end},
+				Replacement: string(expected[0]),
+			}
+		}
+	}
+
+	parser.addError(diagnostic)
+}
+
+// records that the current token cannot start an expression, listing the
+// tokens that can.
+func (parser *Parser) noPrefixParseFnError() {
+	if isLexerError(parser.curToken.Type) {
+		// the lexer has reported this one already
+		parser.panicking = true
+		return
+	}
+
+	diagnostic := diagnostics.Errorf(diagnostics.ExpectedExpression, tokenSpan(parser.curToken),
+		"expected an expression, got %s instead", parser.curToken.Type)
+	diagnostic.Notes = append(diagnostic.Notes,
+		"an expression starts with one of "+quoteTokenTypes(sortedTokenTypes(parser.prefixParseFns)))
+
+	parser.addError(diagnostic)
+}
+
+// helper that records an error for a parse function that returned no
+// expression for the construct starting at start. the built-in ones
+// always record an error of their own first, which this leaves alone, but
+// one registered by an embedder may not.
+func (parser *Parser) missingExpressionError(start token.Token) {
+	if parser.panicking {
+		return
+	}
+
+	parser.addError(diagnostics.Errorf(diagnostics.ExpectedExpression, tokenSpan(start),
+		"expected an expression, got no valid one starting with %s", start.Literal))
+}
+
+// helper that reports whether tokenType is one of the tokens the lexer
+// emits for malformed input, which come with a lexer error of their own.
+func isLexerError(tokenType token.TokenType) bool {
+	switch tokenType {
+	case token.ILLEGAL, token.UNTERMINATED_STRING, token.UNTERMINATED_COMMENT,
+		token.MALFORMED_NUMBER, token.UNTERMINATED_TEMPLATE:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns the token types a parse function is registered for, sorted so
+// that messages listing them are stable.
+func sortedTokenTypes[F any](parseFns map[token.TokenType]F) []token.TokenType {
+	tokenTypes := make([]token.TokenType, 0, len(parseFns))
+	for tokenType := range parseFns {
+		tokenTypes = append(tokenTypes, tokenType)
+	}
+	sort.Slice(tokenTypes, func(i, j int) bool { return tokenTypes[i] < tokenTypes[j] })
+	return tokenTypes
+}
+
+// returns the token types as a list like `")", ",", "+"`.
+func quoteTokenTypes(tokenTypes []token.TokenType) string {
+	quoted := make([]string, len(tokenTypes))
+	for i, tokenType := range tokenTypes {
+		quoted[i] = strconv.Quote(string(tokenType))
+	}
+	return strings.Join(quoted, ", ")
 }
 
-// returns a slice of errors the parser records
-func (parser *Parser) Errors() []string {
-	return parser.errors
+// records diagnostic and puts the parser into panic mode. errors raised
+// while already panicking are follow-ups of the first one and are dropped,
+// so that a single typo yields a single error.
+func (parser *Parser) addError(diagnostic *diagnostics.Diagnostic) {
+	if parser.panicking {
+		return
+	}
+	parser.panicking = true
+
+	parser.errors = append(parser.errors, diagnostic)
+}
+
+// returns the source span of tok. the literal of a string is its decoded
+// value rather than its source text, so only the opening quote is spanned.
+func tokenSpan(tok token.Token) diagnostics.Span {
+	switch tok.Type {
+	case token.STRING:
+		return diagnostics.SpanOf(tok.Position, `"`)
+	case token.TEMPLATE_TEXT:
+		return diagnostics.SpanOf(tok.Position, "")
+	default:
+		return diagnostics.SpanOf(tok.Position, tok.Literal)
+	}
+}
+
+// leaves panic mode by skipping tokens up to the end of the broken
+// statement: a semicolon, the } closing the enclosing block, or the
+// keyword starting the next statement. start is the first token of the
+// broken statement, and braces and parens are the numbers of brackets open
+// in the enclosing block, so that brackets opened by the broken statement
+// itself are skipped as a whole, e.g, the semicolons of a for loop header.
+// on return the current token is the last token of the broken statement,
+// unless the error was found on the } or keyword ending it, e.g, in
+// { 1 + }. in that case synchronize returns true, and the caller must not
+// skip the current token.
+func (parser *Parser) synchronize(start token.Token, braces int, parens int) bool {
+	parser.panicking = false
+
+	// a ( or [ left unclosed by the broken statement must not swallow
+	// the semicolons of the statements after it
+	defer func() { parser.parens = parens }()
+
+	// the } has already been counted as closed once it is the current token
+	if parser.curTokenIs(token.RBRACE) && parser.braces < braces {
+		return true
+	}
+	if parser.curToken.Position.Offset > start.Position.Offset && parser.braces <= braces &&
+		parser.parens <= parens && isStatementKeyword(parser.curToken.Type) {
+		return true
+	}
+
+	for !parser.curTokenIs(token.EOF) {
+		if parser.curTokenIs(token.SEMICOLON) && parser.braces <= braces && parser.parens <= parens {
+			return false
+		}
+
+		if parser.peekTokenIs(token.EOF) {
+			return false
+		}
+		if (parser.peekTokenIs(token.RBRACE) || isStatementKeyword(parser.peekToken.Type)) && parser.braces <= braces {
+			return false
+		}
+
+		parser.nextToken()
+	}
+	return false
+}
+
+// helper that reports whether tokenType is a keyword that can only start
+// a statement.
+func isStatementKeyword(tokenType token.TokenType) bool {
+	switch tokenType {
+	case token.LET, token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+		return true
+	default:
+		return false
+	}
 }
 
-func (parser *Parser) peekError(expectedToken token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		expectedToken, parser.peekToken.Type)
-	parser.errors = append(parser.errors, msg)
+// helper that enters one more level of nesting and returns true. if that
+// would exceed the maximum depth, it records an error instead and returns
+// false, and the caller is supposed to give up on the construct. every
+// successful call must be paired with a call to leaveNesting.
+func (parser *Parser) enterNesting() bool {
+	if parser.depth < parser.config.MaxDepth {
+		parser.depth++
+		return true
+	}
+
+	// blocks resynchronize after the first error, and would otherwise
+	// report the limit again for every construct nested just as deeply
+	if parser.tooDeep {
+		parser.panicking = true
+		return false
+	}
+	parser.tooDeep = true
+
+	parser.addError(diagnostics.Errorf(diagnostics.NestingTooDeep, tokenSpan(parser.curToken),
+		"nesting exceeds the maximum depth of %d", parser.config.MaxDepth))
+	return false
+}
+
+func (parser *Parser) leaveNesting() {
+	parser.depth--
+	if parser.depth == 0 {
+		parser.tooDeep = false
+	}
 }
 
 func (parser *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFunction) {
@@ -89,16 +430,33 @@ func (parser *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for parser.curToken.Type != token.EOF {
+		start := parser.curToken
 		statement := parser.parseStatement()
-		if statement != nil {
+		if parser.panicking {
+			// drop the broken statement, keep parsing the rest of the file
+			if parser.synchronize(start, 0, 0) {
+				continue
+			}
+		} else if statement != nil {
 			program.Statements = append(program.Statements, statement)
 		}
 		parser.nextToken()
 	}
+	program.Comments = parser.comments
 
 	return program
 }
 
+// helper that consumes the semicolon ending a statement, if there is one.
+// semicolons are optional so something like "5 + 5" can be typed in the
+// REPL. after an error the semicolon is left for synchronize, as the error
+// may have been found on the } before it.
+func (parser *Parser) skipOptionalSemicolon() {
+	if !parser.panicking && parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+}
+
 // parses statements as per the current token type and returns a
 // [ast.Statement] node.
 func (parser *Parser) parseStatement() ast.Statement {
@@ -107,6 +465,12 @@ func (parser *Parser) parseStatement() ast.Statement {
 		return parser.parseLetStatement()
 	case token.RETURN:
 		return parser.parseReturnStatement()
+	case token.WHILE:
+		return parser.parseWhileStatement()
+	case token.FOR:
+		return parser.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		return parser.parseLoopControlStatement()
 	default:
 		return parser.parseExpressionStatement()
 	}
@@ -129,10 +493,10 @@ func (parser *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	// TODO: skipping the expressions until encounter a semicolon
-	for !parser.curTokenIs(token.SEMICOLON) {
-		parser.nextToken()
-	}
+	parser.nextToken()
+	letStatement.Value = parser.parseExpression(LOWEST)
+
+	parser.skipOptionalSemicolon()
 
 	return letStatement
 }
@@ -165,14 +529,180 @@ func (parser *Parser) expectPeek(tok token.TokenType) bool {
 func (parser *Parser) parseReturnStatement() *ast.ReturnStatement {
 	returnStatement := &ast.ReturnStatement{Token: parser.curToken}
 
+	// a bare return, like return; or return } at the end of a block, has
+	// no value
+	if parser.peekTokenIs(token.SEMICOLON) || parser.peekTokenIs(token.RBRACE) || parser.peekTokenIs(token.EOF) {
+		parser.skipOptionalSemicolon()
+		return returnStatement
+	}
+
+	parser.nextToken()
+	returnStatement.Value = parser.parseExpression(LOWEST)
+
+	parser.skipOptionalSemicolon()
+
+	return returnStatement
+}
+
+// parses while loops and returns a [ast.WhileStatement] node.
+// supposed to be called when parser.curToken.Type == [token.WHILE].
+func (parser *Parser) parseWhileStatement() ast.Statement {
+	if !parser.requireFeature(Loops) {
+		return nil
+	}
+
+	statement := &ast.WhileStatement{Token: parser.curToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	parser.nextToken()
+	statement.Condition = parser.parseExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	parser.skipOptionalSemicolon()
+
+	return statement
+}
+
+// parses both C-style for loops and for-in loops, and returns a
+// [ast.ForStatement] or [ast.ForInStatement] node respectively.
+// supposed to be called when parser.curToken.Type == [token.FOR].
+func (parser *Parser) parseForStatement() ast.Statement {
+	if !parser.requireFeature(Loops) {
+		return nil
+	}
+
+	forToken := parser.curToken
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
 	parser.nextToken()
 
-	// TODO: skipping the expressions until encounter a semicolon
-	for !parser.curTokenIs(token.SEMICOLON) {
+	if parser.curTokenIs(token.IDENT) && parser.peekTokenIs(token.IN) {
+		return parser.parseForInStatement(forToken)
+	}
+
+	statement := &ast.ForStatement{Token: forToken}
+
+	// the init clause; cur is its first token, or the ; if it is empty
+	switch parser.curToken.Type {
+	case token.SEMICOLON:
+	case token.LET:
+		init := parser.parseLetStatement()
+		if init == nil {
+			return nil
+		}
+		// the let statement has consumed the ; if there was one
+		if !parser.curTokenIs(token.SEMICOLON) {
+			parser.peekError(token.SEMICOLON)
+			return nil
+		}
+		statement.Init = init
+	default:
+		statement.Init = &ast.ExpressionStatement{Token: parser.curToken, Expression: parser.parseExpression(LOWEST)}
+		if !parser.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	if !parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+		statement.Condition = parser.parseExpression(LOWEST)
+	}
+	if !parser.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+
+	if !parser.peekTokenIs(token.RPAREN) {
 		parser.nextToken()
+		statement.Update = parser.parseExpression(LOWEST)
+	}
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
 	}
 
-	return returnStatement
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	parser.skipOptionalSemicolon()
+
+	return statement
+}
+
+// parses the rest of a for-in loop and returns a [ast.ForInStatement]
+// node.
+// supposed to be called with the loop variable as the current token.
+func (parser *Parser) parseForInStatement(forToken token.Token) ast.Statement {
+	statement := &ast.ForInStatement{Token: forToken}
+	statement.Variable = &ast.Identifier{Token: parser.curToken, Value: parser.curToken.Literal}
+
+	parser.nextToken()
+	parser.nextToken()
+	statement.Iterable = parser.parseExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	parser.skipOptionalSemicolon()
+
+	return statement
+}
+
+// parses the body of a loop, in which break and continue are allowed.
+// supposed to be called when parser.curToken.Type == [token.LBRACE].
+func (parser *Parser) parseLoopBody() *ast.BlockStatement {
+	parser.loops++
+	defer func() { parser.loops-- }()
+
+	return parser.parseBlockStatement()
+}
+
+// parses break and continue statements and returns a [ast.BreakStatement]
+// or [ast.ContinueStatement] node. reports an error when there is no
+// loop to break out of or continue.
+func (parser *Parser) parseLoopControlStatement() ast.Statement {
+	if !parser.requireFeature(Loops) {
+		return nil
+	}
+
+	var statement ast.Statement
+	if parser.curTokenIs(token.BREAK) {
+		statement = &ast.BreakStatement{Token: parser.curToken}
+	} else {
+		statement = &ast.ContinueStatement{Token: parser.curToken}
+	}
+
+	if parser.loops == 0 {
+		parser.addError(diagnostics.Errorf(diagnostics.LoopControlOutsideLoop, tokenSpan(parser.curToken),
+			"%s outside of a loop", parser.curToken.Literal))
+		return nil
+	}
+
+	parser.skipOptionalSemicolon()
+
+	return statement
 }
 
 func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
@@ -180,24 +710,639 @@ func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	expStatement.Expression = parser.parseExpression(LOWEST)
 
-	// optional semicolon so something like "5 + 5" can be typed in REPL
-	if parser.peekTokenIs(token.SEMICOLON) {
-		parser.nextToken()
-	}
+	parser.skipOptionalSemicolon()
 
 	return expStatement
 }
 
+// the Pratt parsing loop: parses a prefix expression starting at the
+// current token, then keeps folding it into infix expressions for as long
+// as the next operator binds tighter than precedence.
 func (parser *Parser) parseExpression(precedence int) ast.Expression {
+	if !parser.enterNesting() {
+		return nil
+	}
+	defer parser.leaveNesting()
+
 	prefixParseFn := parser.prefixParseFns[parser.curToken.Type]
 	if prefixParseFn == nil {
+		parser.noPrefixParseFnError()
 		return nil
 	}
 
+	start := parser.curToken
 	leftExp := prefixParseFn()
+	if leftExp == nil {
+		parser.missingExpressionError(start)
+	}
+
+	for !parser.panicking && !parser.peekTokenIs(token.SEMICOLON) && precedence < parser.peekPrecedence() {
+		infixParseFn := parser.infixParseFns[parser.peekToken.Type]
+		if infixParseFn == nil {
+			break
+		}
+
+		parser.nextToken()
+		operator := parser.curToken
+		leftExp = infixParseFn(leftExp)
+		if leftExp == nil {
+			parser.missingExpressionError(operator)
+		}
+	}
+
+	parser.afterExpression = true
 	return leftExp
 }
 
+// returns the precedence of the next token, or LOWEST if it is not an
+// infix operator.
+func (parser *Parser) peekPrecedence() int {
+	if precedence, ok := parser.precedences[parser.peekToken.Type]; ok {
+		return precedence
+	}
+	return LOWEST
+}
+
+// returns the precedence of the current token, or LOWEST if it is not an
+// infix operator.
+func (parser *Parser) curPrecedence() int {
+	if precedence, ok := parser.precedences[parser.curToken.Type]; ok {
+		return precedence
+	}
+	return LOWEST
+}
+
 func (parser *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: parser.curToken, Value: parser.curToken.Literal}
 }
+
+// parses integer literals in decimal, hexadecimal, octal or binary
+// notation, optionally with '_' digit separators.
+func (parser *Parser) parseIntegerLiteral() ast.Expression {
+	literal := &ast.IntegerLiteral{Token: parser.curToken}
+
+	// base 0 would read a leading 0 as octal, so only use it for prefixed
+	// literals such as 0xFF
+	digits, base := parser.curToken.Literal, 10
+	if len(digits) > 1 && digits[0] == '0' && strings.ContainsAny(digits[1:2], "xXoObB") {
+		base = 0
+	} else {
+		digits = strings.ReplaceAll(digits, "_", "")
+	}
+
+	value, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		parser.addError(diagnostics.Errorf(diagnostics.InvalidLiteral, tokenSpan(parser.curToken),
+			"could not parse %q as integer", parser.curToken.Literal))
+		return nil
+	}
+
+	literal.Value = value
+	return literal
+}
+
+func (parser *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: parser.curToken}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(parser.curToken.Literal, "_", ""), 64)
+	if err != nil {
+		parser.addError(diagnostics.Errorf(diagnostics.InvalidLiteral, tokenSpan(parser.curToken),
+			"could not parse %q as float", parser.curToken.Literal))
+		return nil
+	}
+
+	literal.Value = value
+	return literal
+}
+
+func (parser *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: parser.curToken, Value: parser.curToken.Literal}
+}
+
+func (parser *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: parser.curToken, Value: parser.curTokenIs(token.TRUE)}
+}
+
+func (parser *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: parser.curToken}
+}
+
+// parses prefix expressions like -5 or !ok and returns a
+// [ast.PrefixExpression] node.
+func (parser *Parser) parsePrefixExpression() ast.Expression {
+	expression := &ast.PrefixExpression{
+		Token:    parser.curToken,
+		Operator: parser.curToken.Literal,
+	}
+
+	parser.nextToken()
+	expression.Right = parser.parseExpression(PREFIX)
+
+	return expression
+}
+
+// parses binary expressions like a + b and returns a [ast.InfixExpression]
+// node. supposed to be called with the operator as the current token and
+// the already parsed left operand.
+func (parser *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    parser.curToken,
+		Operator: parser.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := parser.curPrecedence()
+	parser.nextToken()
+	expression.Right = parser.parseExpression(precedence)
+
+	return expression
+}
+
+// parses assignments like x = 5 or arr[i] += 2 and returns a
+// [ast.AssignExpression] node. assignments are right-associative, so
+// a = b = c assigns c to b and then to a.
+// supposed to be called with the operator as the current token and the
+// already parsed target.
+func (parser *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	if !parser.requireFeature(Assignments) {
+		return nil
+	}
+
+	expression := &ast.AssignExpression{
+		Token:    parser.curToken,
+		Target:   target,
+		Operator: parser.curToken.Literal,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		diagnostic := diagnostics.Errorf(diagnostics.InvalidAssignmentTarget, tokenSpan(parser.curToken),
+			"invalid left-hand side of assignment")
+		diagnostic.Notes = append(diagnostic.Notes,
+			fmt.Sprintf("cannot assign to %s, only to a name like x or an index like arr[i]", target))
+		parser.addError(diagnostic)
+		return nil
+	}
+
+	// one less than the operator's own precedence makes the right side
+	// swallow any further assignment
+	precedence := parser.curPrecedence()
+	parser.nextToken()
+	expression.Value = parser.parseExpression(precedence - 1)
+
+	return expression
+}
+
+// parses null-coalescing expressions like a ?? b and returns a
+// [ast.InfixExpression] node. ?? is right-associative, so a ?? b ?? c
+// falls back to b ?? c when a is null.
+// supposed to be called with the ?? as the current token and the already
+// parsed left operand.
+func (parser *Parser) parseCoalesceExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    parser.curToken,
+		Operator: parser.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := parser.curPrecedence()
+	parser.nextToken()
+	expression.Right = parser.parseExpression(precedence - 1)
+
+	return expression
+}
+
+// parses conditional expressions like x > 0 ? x : -x and returns a
+// [ast.ConditionalExpression] node. they are right-associative, so
+// a ? b : c ? d : e chooses between b and c ? d : e.
+// supposed to be called with the ? as the current token and the already
+// parsed condition.
+func (parser *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{
+		Token:     parser.curToken,
+		Condition: condition,
+	}
+
+	precedence := parser.curPrecedence()
+	parser.nextToken()
+	// the : ends the consequence like a closing bracket would, so it may
+	// hold any expression, another conditional included
+	expression.Consequence = parser.parseExpression(LOWEST)
+	if !parser.expectPeek(token.COLON) {
+		return nil
+	}
+
+	parser.nextToken()
+	expression.Alternative = parser.parseExpression(precedence - 1)
+
+	return expression
+}
+
+// parses parenthesized expressions like (a + b); the parentheses only
+// steer precedence and leave no node of their own.
+func (parser *Parser) parseGroupedExpression() ast.Expression {
+	parser.nextToken()
+
+	expression := parser.parseExpression(LOWEST)
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	// a pipe calls a call in parentheses rather than extending it
+	if call, ok := expression.(*ast.CallExpression); ok {
+		call.Grouped = true
+	}
+
+	return expression
+}
+
+// parses a template string and returns a [ast.TemplateLiteral] node.
+// supposed to be called when parser.curToken.Type == [token.TEMPLATE_START].
+func (parser *Parser) parseTemplateLiteral() ast.Expression {
+	if !parser.requireFeature(TemplateStrings) {
+		return nil
+	}
+
+	template := &ast.TemplateLiteral{Token: parser.curToken}
+	chunk := ""
+
+	for !parser.peekTokenIs(token.TEMPLATE_END) {
+		switch parser.peekToken.Type {
+		case token.TEMPLATE_TEXT:
+			parser.nextToken()
+			chunk += parser.curToken.Literal
+		case token.TEMPLATE_EXPR_START:
+			parser.nextToken()
+			if parser.peekTokenIs(token.TEMPLATE_EXPR_END) {
+				parser.addError(diagnostics.Errorf(diagnostics.EmptyTemplateExpr, tokenSpan(parser.curToken),
+					"expected an expression inside ${}"))
+				return nil
+			}
+			parser.nextToken()
+
+			template.Strings = append(template.Strings, chunk)
+			template.Expressions = append(template.Expressions, parser.parseExpression(LOWEST))
+			chunk = ""
+
+			if !parser.expectPeek(token.TEMPLATE_EXPR_END) {
+				return nil
+			}
+		default:
+			parser.peekError(token.TEMPLATE_END)
+			return nil
+		}
+	}
+
+	parser.nextToken()
+	template.Strings = append(template.Strings, chunk)
+	return template
+}
+
+// parses if expressions, including `else if` chains, and returns a
+// [ast.IfExpression] node.
+// supposed to be called when parser.curToken.Type == [token.IF].
+func (parser *Parser) parseIfExpression() ast.Expression {
+	// else if chains recurse here without going through parseExpression
+	if !parser.enterNesting() {
+		return nil
+	}
+	defer parser.leaveNesting()
+
+	expression := &ast.IfExpression{Token: parser.curToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	parser.nextToken()
+	expression.Condition = parser.parseExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Consequence = parser.parseBlockStatement()
+
+	if !parser.peekTokenIs(token.ELSE) {
+		return expression
+	}
+	parser.nextToken()
+
+	// else if: wrap the nested if expression in a block of its own
+	if parser.peekTokenIs(token.IF) {
+		parser.nextToken()
+		ifToken := parser.curToken
+
+		nested := parser.parseIfExpression()
+		if nested == nil {
+			return nil
+		}
+
+		expression.Alternative = &ast.BlockStatement{
+			Token:      ifToken,
+			Statements: []ast.Statement{&ast.ExpressionStatement{Token: ifToken, Expression: nested}},
+		}
+		return expression
+	}
+
+	if !parser.peekTokenIs(token.LBRACE) {
+		parser.peekError(token.LBRACE, token.IF)
+		return nil
+	}
+	parser.nextToken()
+
+	expression.Alternative = parser.parseBlockStatement()
+
+	return expression
+}
+
+// parses the statements up to the matching } and returns a
+// [ast.BlockStatement] node.
+// supposed to be called when parser.curToken.Type == [token.LBRACE].
+func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
+	// the block would resynchronize and so hide an error raised before it,
+	// e.g, in the header of a loop
+	if parser.panicking {
+		return nil
+	}
+
+	if !parser.enterNesting() {
+		return nil
+	}
+	defer parser.leaveNesting()
+
+	block := &ast.BlockStatement{Token: parser.curToken}
+	block.Statements = []ast.Statement{}
+	braces, parens := parser.braces, parser.parens
+
+	parser.blocks = append(parser.blocks, block.Token)
+	defer func() { parser.blocks = parser.blocks[:len(parser.blocks)-1] }()
+
+	parser.nextToken()
+
+	for !parser.curTokenIs(token.RBRACE) && !parser.curTokenIs(token.EOF) {
+		start := parser.curToken
+		statement := parser.parseStatement()
+		if parser.panicking {
+			if parser.synchronize(start, braces, parens) {
+				continue
+			}
+		} else if statement != nil {
+			block.Statements = append(block.Statements, statement)
+		}
+		parser.nextToken()
+	}
+
+	if parser.curTokenIs(token.EOF) && !parser.unclosedBlocks {
+		parser.unclosedBlockError()
+	}
+
+	return block
+}
+
+// the number of enclosing blocks listed in the notes of an unclosed block
+// error before the rest are only counted
+const maxUnclosedBlockNotes = 3
+
+// helper that reports the blocks being parsed as left open at EOF. the
+// error is raised by the innermost block and covers all enclosing ones, so
+// these do not report EOF again once they recover from it.
+func (parser *Parser) unclosedBlockError() {
+	parser.unclosedBlocks = true
+
+	diagnostic := diagnostics.Errorf(diagnostics.UnclosedBlock, tokenSpan(parser.curToken),
+		"expected } to close the block, got EOF instead")
+
+	innermost := len(parser.blocks) - 1
+	diagnostic.Notes = append(diagnostic.Notes,
+		fmt.Sprintf("the block was opened at %s", parser.blocks[innermost].Position))
+	for i := innermost - 1; i >= 0 && i >= innermost-maxUnclosedBlockNotes; i-- {
+		diagnostic.Notes = append(diagnostic.Notes,
+			fmt.Sprintf("the enclosing block opened at %s is not closed either", parser.blocks[i].Position))
+	}
+	if innermost > maxUnclosedBlockNotes {
+		diagnostic.Notes = append(diagnostic.Notes,
+			fmt.Sprintf("and %d more enclosing blocks", innermost-maxUnclosedBlockNotes))
+	}
+
+	closing := strings.Repeat("}", len(parser.blocks))
+	diagnostic.Fix = &diagnostics.Fix{
+		Message:     fmt.Sprintf("insert %q", closing),
+		Span:        tokenSpan(parser.curToken),
+		Replacement: closing,
+	}
+	parser.addError(diagnostic)
+}
+
+// parses function literals and returns a [ast.FunctionLiteral] node.
+// supposed to be called when parser.curToken.Type == [token.FUNCTION].
+func (parser *Parser) parseFunctionLiteral() ast.Expression {
+	function := &ast.FunctionLiteral{Token: parser.curToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	function.Parameters = parser.parseFunctionParameters()
+	if function.Parameters == nil {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	// break and continue cannot reach the loops around a function
+	loops := parser.loops
+	parser.loops = 0
+	function.Body = parser.parseBlockStatement()
+	parser.loops = loops
+
+	return function
+}
+
+// parses a comma separated list of parameter names up to the closing ),
+// which may be empty or end with a trailing comma. returns nil if the list
+// is malformed.
+// supposed to be called when parser.curToken.Type == [token.LPAREN].
+func (parser *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	if parser.peekTokenIs(token.RPAREN) {
+		parser.nextToken()
+		return identifiers
+	}
+
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: parser.curToken, Value: parser.curToken.Literal})
+
+	for parser.peekTokenIs(token.COMMA) {
+		parser.nextToken()
+		if parser.peekTokenIs(token.RPAREN) {
+			break // trailing comma
+		}
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: parser.curToken, Value: parser.curToken.Literal})
+	}
+
+	if !parser.peekTokenIs(token.RPAREN) {
+		parser.peekError(token.RPAREN, token.COMMA)
+		return nil
+	}
+	parser.nextToken()
+
+	return identifiers
+}
+
+// parses call expressions like add(1, 2) and returns a
+// [ast.CallExpression] node. supposed to be called with the ( as the
+// current token and the already parsed function expression.
+func (parser *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: parser.curToken, Function: function}
+
+	expression.Arguments = parser.parseExpressionList(token.RPAREN)
+	if expression.Arguments == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// parses pipes like x |> f(a) and returns the [ast.CallExpression] node
+// they stand for, i.e, f(x, a), with x as the first argument. a pipe into
+// anything but a call, like x |> f, calls it with x as the only argument.
+// supposed to be called with the |> as the current token and the already
+// parsed left operand.
+func (parser *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	pipeToken := parser.curToken
+
+	precedence := parser.curPrecedence()
+	parser.nextToken()
+	right := parser.parseExpression(precedence)
+	if right == nil {
+		return nil
+	}
+
+	// a call on the right in parentheses, e.g, x |> (makeAdder(1)) or
+	// x |> (y |> f), is the function being called rather than the call to
+	// extend
+	if call, ok := right.(*ast.CallExpression); ok && !call.Grouped {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		call.Piped = true
+		return call
+	}
+
+	return &ast.CallExpression{
+		Token:     pipeToken,
+		Function:  right,
+		Arguments: []ast.Expression{left},
+		Piped:     true,
+	}
+}
+
+// parses array literals like [1, 2, 3] and returns a [ast.ArrayLiteral]
+// node.
+// supposed to be called when parser.curToken.Type == [token.LBRACKET].
+func (parser *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: parser.curToken}
+
+	array.Elements = parser.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+
+	return array
+}
+
+// parses a comma separated list of expressions up to the given end token,
+// which may be empty or end with a trailing comma. returns nil if the list
+// is malformed.
+func (parser *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if parser.peekTokenIs(end) {
+		parser.nextToken()
+		return list
+	}
+
+	parser.nextToken()
+	list = append(list, parser.parseExpression(LOWEST))
+
+	for parser.peekTokenIs(token.COMMA) {
+		parser.nextToken()
+		if parser.peekTokenIs(end) {
+			break // trailing comma
+		}
+		parser.nextToken()
+		list = append(list, parser.parseExpression(LOWEST))
+	}
+
+	if !parser.peekTokenIs(end) {
+		parser.peekError(end, token.COMMA)
+		return nil
+	}
+	parser.nextToken()
+
+	return list
+}
+
+// parses index expressions like myArray[0] and returns a
+// [ast.IndexExpression] node. supposed to be called with the [ as the
+// current token and the already parsed indexed expression.
+func (parser *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{Token: parser.curToken, Left: left}
+
+	parser.nextToken()
+	expression.Index = parser.parseExpression(LOWEST)
+
+	if !parser.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return expression
+}
+
+// parses hash literals like {"name": "SaSi", "age": 28} and returns a
+// [ast.HashLiteral] node. a trailing comma after the last pair is allowed.
+// supposed to be called when parser.curToken.Type == [token.LBRACE].
+func (parser *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: parser.curToken}
+	hash.Pairs = []ast.HashPair{}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		parser.nextToken()
+		key := parser.parseExpression(LOWEST)
+
+		if !parser.expectPeek(token.COLON) {
+			return nil
+		}
+
+		parser.nextToken()
+		value := parser.parseExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !parser.peekTokenIs(token.RBRACE) {
+			if !parser.peekTokenIs(token.COMMA) {
+				parser.peekError(token.RBRACE, token.COMMA)
+				return nil
+			}
+			parser.nextToken()
+		}
+	}
+
+	if !parser.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
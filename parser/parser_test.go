@@ -1,46 +1,88 @@
 package parser
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/self-sasi/monkey-interpreter/ast"
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
 	"github.com/self-sasi/monkey-interpreter/lexer"
+	"github.com/self-sasi/monkey-interpreter/token"
 )
 
 func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true;", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+		{"let noSemicolon = 10", "noSemicolon", 10},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.New(tt.input)
+		parser := New(lexer)
+
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
+		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
+			return
+		}
+
+		value := stmt.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, value, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestStatementsWithoutSemicolons(t *testing.T) {
 	input := `
-		let x = 5;
-		let y = 10;
-		let foobar = 838383;
+		let x = 5
+		let y = x + 1
+		return x * y
+		x
 	`
 
 	lexer := lexer.New(input)
 	parser := New(lexer)
 
 	program := parser.ParseProgram()
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
-	}
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements. got=%d",
-			len(program.Statements))
-	}
-
 	checkParserErrors(t, parser)
 
-	tests := []struct {
-		expectedIdentifier string
-	}{
-		{"x"},
-		{"y"},
-		{"foobar"},
+	expected := "let x = 5;let y = (x + 1);return (x * y);x"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
 	}
+}
 
-	for i, testCase := range tests {
-		stmt := program.Statements[i]
-		if !testLetStatement(t, stmt, testCase.expectedIdentifier) {
-			return
-		}
+func TestStatementsStopAtEOF(t *testing.T) {
+	tests := []string{
+		"let",
+		"let x",
+		"let x =",
+		"let x = ",
+		"return",
+		"return ",
+	}
+
+	for _, input := range tests {
+		lexer := lexer.New(input)
+		parser := New(lexer)
+
+		// must terminate rather than loop looking for a semicolon
+		parser.ParseProgram()
 	}
 }
 
@@ -85,31 +127,56 @@ func checkParserErrors(t *testing.T, parser *Parser) {
 }
 
 func TestReturnStatement(t *testing.T) {
-	input := `
-		return 5;
-		return foo;
-		return 10;
-	`
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"return 5;", 5},
+		{"return foo;", "foo"},
+		{"return true", true},
+		{"return;", nil},
+		{"return", nil},
+	}
 
-	lexer := lexer.New(input)
-	parser := New(lexer)
+	for _, tt := range tests {
+		lexer := lexer.New(tt.input)
+		parser := New(lexer)
 
-	program := parser.ParseProgram()
-	checkParserErrors(t, parser)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
 
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
-	}
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
 
-	for _, statement := range program.Statements {
-		returnStatement, ok := statement.(*ast.ReturnStatement)
+		returnStatement, ok := program.Statements[0].(*ast.ReturnStatement)
 		if !ok {
-			t.Errorf("returnStatement not *ast.returnStatement. got=%T", statement)
-			continue
+			t.Fatalf("returnStatement not *ast.returnStatement. got=%T", program.Statements[0])
 		}
 		if returnStatement.TokenLiteral() != "return" {
 			t.Errorf("returnStatement.TokenLiteral not 'return', got %q", returnStatement.TokenLiteral())
 		}
+		if tt.expectedValue == nil {
+			if returnStatement.Value != nil {
+				t.Errorf("returnStatement.Value not nil. got=%T (%s)", returnStatement.Value, returnStatement.Value)
+			}
+			continue
+		}
+		if !testLiteralExpression(t, returnStatement.Value, tt.expectedValue) {
+			return
+		}
+	}
+
+	input := "fn() { return }; fn(x) { if (x) { return; } x }"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := "fn() return nil;fn(x) ifx return nil;x"
+	if program.String() != expected {
+		t.Errorf("program wrong. expected=%q, got=%q", expected, program.String())
 	}
 }
 
@@ -144,3 +211,1749 @@ func TestIdentifierExpression(t *testing.T) {
 			ident.TokenLiteral())
 	}
 }
+
+func TestTemplateLiteral(t *testing.T) {
+	tests := []struct {
+		input               string
+		expectedStrings     []string
+		expectedExpressions []string
+		expectedString      string
+	}{
+		{"`plain`", []string{"plain"}, []string{}, "`plain`"},
+		{"``", []string{""}, []string{}, "``"},
+		{"`hello ${user}, you have ${count} items`",
+			[]string{"hello ", ", you have ", " items"}, []string{"user", "count"},
+			"`hello ${user}, you have ${count} items`"},
+		{"`${a}${b}`", []string{"", "", ""}, []string{"a", "b"}, "`${a}${b}`"},
+		{"`tick \\` dollar \\${x} slash \\\\`",
+			[]string{"tick ` dollar ${x} slash \\"}, []string{},
+			"`tick \\` dollar \\${x} slash \\\\`"},
+		{"`outer ${`inner ${x}`}`", []string{"outer ", ""}, []string{"`inner ${x}`"},
+			"`outer ${`inner ${x}`}`"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		template, ok := stmt.Expression.(*ast.TemplateLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.TemplateLiteral. got=%T", stmt.Expression)
+		}
+
+		if len(template.Strings) != len(tt.expectedStrings) {
+			t.Fatalf("%q: wrong number of strings. expected=%d, got=%d",
+				tt.input, len(tt.expectedStrings), len(template.Strings))
+		}
+		for i, expected := range tt.expectedStrings {
+			if template.Strings[i] != expected {
+				t.Errorf("%q: Strings[%d] wrong. expected=%q, got=%q",
+					tt.input, i, expected, template.Strings[i])
+			}
+		}
+
+		if len(template.Expressions) != len(tt.expectedExpressions) {
+			t.Fatalf("%q: wrong number of expressions. expected=%d, got=%d",
+				tt.input, len(tt.expectedExpressions), len(template.Expressions))
+		}
+		for i, expected := range tt.expectedExpressions {
+			if template.Expressions[i].String() != expected {
+				t.Errorf("%q: Expressions[%d] wrong. expected=%q, got=%q",
+					tt.input, i, expected, template.Expressions[i].String())
+			}
+		}
+
+		if template.String() != tt.expectedString {
+			t.Errorf("%q: String() wrong. expected=%q, got=%q",
+				tt.input, tt.expectedString, template.String())
+		}
+	}
+}
+
+func TestTemplateLiteralErrors(t *testing.T) {
+	tests := []string{
+		"`abc ${}`",
+		"`abc ${x",
+		"`abc",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parser errors, got none", input)
+		}
+	}
+}
+
+func TestLiteralExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"5;", int64(5)},
+		{"0xFF;", int64(255)},
+		{"0o755;", int64(493)},
+		{"0b1010;", int64(10)},
+		{"1_000_000;", int64(1000000)},
+		{"0755;", int64(755)},
+		{"3.14;", 3.14},
+		{"1e-9;", 1e-9},
+		{"1_000.5;", 1000.5},
+		{`"hello\tworld";`, "hello\tworld"},
+		{"true;", true},
+		{"false;", false},
+		{"null;", nil},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has not enough statements. got=%d",
+				len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		testLiteralExpression(t, stmt.Expression, tt.expected)
+	}
+}
+
+func TestIntegerOverflow(t *testing.T) {
+	p := New(lexer.New("99999999999999999999;"))
+	p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 parser error, got=%d: %v", len(p.Errors()), p.Errors())
+	}
+}
+
+func TestParsingPrefixExpressions(t *testing.T) {
+	prefixTests := []struct {
+		input    string
+		operator string
+		value    interface{}
+	}{
+		{"!5;", "!", 5},
+		{"-15;", "-", 15},
+		{"!foobar;", "!", "foobar"},
+		{"-foobar;", "-", "foobar"},
+		{"!true;", "!", true},
+		{"!false;", "!", false},
+	}
+
+	for _, tt := range prefixTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+				1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		exp, ok := stmt.Expression.(*ast.PrefixExpression)
+		if !ok {
+			t.Fatalf("stmt is not ast.PrefixExpression. got=%T", stmt.Expression)
+		}
+		if exp.Operator != tt.operator {
+			t.Fatalf("exp.Operator is not '%s'. got=%s", tt.operator, exp.Operator)
+		}
+		if !testLiteralExpression(t, exp.Right, tt.value) {
+			return
+		}
+	}
+}
+
+func TestParsingInfixExpressions(t *testing.T) {
+	infixTests := []struct {
+		input      string
+		leftValue  interface{}
+		operator   string
+		rightValue interface{}
+	}{
+		{"5 + 5;", 5, "+", 5},
+		{"5 - 5;", 5, "-", 5},
+		{"5 * 5;", 5, "*", 5},
+		{"5 / 5;", 5, "/", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 > 5;", 5, ">", 5},
+		{"5 < 5;", 5, "<", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 == 5;", 5, "==", 5},
+		{"5 != 5;", 5, "!=", 5},
+		{"foobar + barfoo;", "foobar", "+", "barfoo"},
+		{"true == true", true, "==", true},
+		{"true != false", true, "!=", false},
+		{"a && b", "a", "&&", "b"},
+		{"a || b", "a", "||", "b"},
+		{"a ?? null", "a", "??", nil},
+	}
+
+	for _, tt := range infixTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+				1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if !testInfixExpression(t, stmt.Expression, tt.leftValue,
+			tt.operator, tt.rightValue) {
+			return
+		}
+	}
+}
+
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-a * b", "((-a) * b)"},
+		{"!-a", "(!(-a))"},
+		{"a + b + c", "((a + b) + c)"},
+		{"a + b - c", "((a + b) - c)"},
+		{"a * b * c", "((a * b) * c)"},
+		{"a * b / c", "((a * b) / c)"},
+		{"a + b / c", "(a + (b / c))"},
+		{"a + b % c", "(a + (b % c))"},
+		{"a + b * c + d / e - f", "(((a + (b * c)) + (d / e)) - f)"},
+		{"3 + 4; -5 * 5", "(3 + 4)((-5) * 5)"},
+		{"5 > 4 == 3 < 4", "((5 > 4) == (3 < 4))"},
+		{"5 <= 4 != 3 >= 4", "((5 <= 4) != (3 >= 4))"},
+		{"3 + 4 * 5 == 3 * 1 + 4 * 5", "((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))"},
+		{"true", "true"},
+		{"3 > 5 == false", "((3 > 5) == false)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c && d", "((a && b) || (c && d))"},
+		{"a == b && c != d", "((a == b) && (c != d))"},
+		{"!a || b", "((!a) || b)"},
+		{"1 + (2 + 3) + 4", "((1 + (2 + 3)) + 4)"},
+		{"(5 + 5) * 2", "((5 + 5) * 2)"},
+		{"2 / (5 + 5)", "(2 / (5 + 5))"},
+		{"-(5 + 5)", "(-(5 + 5))"},
+		{"!(true == true)", "(!(true == true))"},
+		{"((a))", "a"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))", "add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))"},
+		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
+		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
+		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"-a[0]", "(-(a[0]))"},
+		{"f(x)(y)[0]", "(f(x)(y)[0])"},
+		{"x = y + 1", "(x = (y + 1))"},
+		{"a = b = c", "(a = (b = c))"},
+		{"a += b -= c * 2", "(a += (b -= (c * 2)))"},
+		{"a[i + 1] = x || y", "((a[(i + 1)]) = (x || y))"},
+		{"x = fn(a) { a = a * 2 }", "(x = fn(a) (a = (a * 2)))"},
+		{"add(x = 1, y)", "add((x = 1), y)"},
+		{"x |> f", "(x |> f)"},
+		{"x |> f(a)", "(x |> f(a))"},
+		{"x |> f(a) |> g(b, c)", "((x |> f(a)) |> g(b, c))"},
+		{"x |> (y |> f)", "(x |> (y |> f))"},
+		{"x |> (makeAdder(1))", "(x |> (makeAdder(1)))"},
+		{"x |> (makeAdder(1))(2)", "(x |> makeAdder(1)(2))"},
+		{"x |> (f)(1)", "(x |> f(1))"},
+		{"a + b |> f", "((a + b) |> f)"},
+		{"a == b |> f", "((a == b) |> f)"},
+		{"a < b |> f(c * d)", "((a < b) |> f((c * d)))"},
+		{"x |> f && y |> g", "((x |> f) && (y |> g))"},
+		{"v = x |> f", "(v = (x |> f))"},
+		{"x |> fn(a, b) { a + b }(1)", "(x |> fn(a, b) (a + b)(1))"},
+		{"[1, 2] |> map(fn(x) { x * 2 })[0]", "([1, 2] |> (map(fn(x) (x * 2))[0]))"},
+		{"a ? b : c", "(a ? b : c)"},
+		{"a ? b : c ? d : e", "(a ? b : (c ? d : e))"},
+		{"a ? b ? c : d : e", "(a ? (b ? c : d) : e)"},
+		{"(a ? b : c) ? d : e", "((a ? b : c) ? d : e)"},
+		{"a == b ? c + 1 : d * 2", "((a == b) ? (c + 1) : (d * 2))"},
+		{"a || b ? c && d : e", "((a || b) ? (c && d) : e)"},
+		{"x = a ? b : c", "(x = (a ? b : c))"},
+		{"a ? x = b : c", "(a ? (x = b) : c)"},
+		{"a ? b : c |> f", "(a ? b : (c |> f))"},
+		{"f(a ? b : c, d)", "f((a ? b : c), d)"},
+		{"[a ? b : c][0]", "([(a ? b : c)][0])"},
+		{"a ?? b ?? c", "(a ?? (b ?? c))"},
+		{"a ?? b || c", "(a ?? (b || c))"},
+		{"a || b ?? c", "((a || b) ?? c)"},
+		{"x |> f ?? y", "((x |> f) ?? y)"},
+		{"a ?? b ? c : d", "((a ?? b) ? c : d)"},
+		{"a ? b ?? c : d ?? e", "(a ? (b ?? c) : (d ?? e))"},
+		{"x = a ?? b", "(x = (a ?? b))"},
+		{"null ?? -1", "(null ?? (-1))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
+	integ, ok := il.(*ast.IntegerLiteral)
+	if !ok {
+		t.Errorf("il not *ast.IntegerLiteral. got=%T", il)
+		return false
+	}
+
+	if integ.Value != value {
+		t.Errorf("integ.Value not %d. got=%d", value, integ.Value)
+		return false
+	}
+
+	return true
+}
+
+func testIdentifier(t *testing.T, exp ast.Expression, value string) bool {
+	ident, ok := exp.(*ast.Identifier)
+	if !ok {
+		t.Errorf("exp not *ast.Identifier. got=%T", exp)
+		return false
+	}
+
+	if ident.Value != value {
+		t.Errorf("ident.Value not %s. got=%s", value, ident.Value)
+		return false
+	}
+
+	if ident.TokenLiteral() != value {
+		t.Errorf("ident.TokenLiteral not %s. got=%s", value,
+			ident.TokenLiteral())
+		return false
+	}
+
+	return true
+}
+
+func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
+	boolean, ok := exp.(*ast.Boolean)
+	if !ok {
+		t.Errorf("exp not *ast.Boolean. got=%T", exp)
+		return false
+	}
+
+	if boolean.Value != value {
+		t.Errorf("boolean.Value not %t. got=%t", value, boolean.Value)
+		return false
+	}
+
+	return true
+}
+
+func testLiteralExpression(t *testing.T, exp ast.Expression, expected interface{}) bool {
+	switch v := expected.(type) {
+	case int:
+		return testIntegerLiteral(t, exp, int64(v))
+	case int64:
+		return testIntegerLiteral(t, exp, v)
+	case float64:
+		float, ok := exp.(*ast.FloatLiteral)
+		if !ok || float.Value != v {
+			t.Errorf("exp not *ast.FloatLiteral with value %g. got=%T (%s)", v, exp, exp)
+			return false
+		}
+		return true
+	case string:
+		if str, ok := exp.(*ast.StringLiteral); ok {
+			if str.Value != v {
+				t.Errorf("str.Value not %q. got=%q", v, str.Value)
+				return false
+			}
+			return true
+		}
+		return testIdentifier(t, exp, v)
+	case bool:
+		return testBooleanLiteral(t, exp, v)
+	case nil:
+		if _, ok := exp.(*ast.NullLiteral); !ok {
+			t.Errorf("exp not *ast.NullLiteral. got=%T (%s)", exp, exp)
+			return false
+		}
+		return true
+	}
+	t.Errorf("type of exp not handled. got=%T", exp)
+	return false
+}
+
+func testInfixExpression(t *testing.T, exp ast.Expression, left interface{},
+	operator string, right interface{}) bool {
+
+	opExp, ok := exp.(*ast.InfixExpression)
+	if !ok {
+		t.Errorf("exp is not ast.InfixExpression. got=%T(%s)", exp, exp)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Left, left) {
+		return false
+	}
+
+	if opExp.Operator != operator {
+		t.Errorf("exp.Operator is not '%s'. got=%q", operator, opExp.Operator)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Right, right) {
+		return false
+	}
+
+	return true
+}
+
+func TestRetainedComments(t *testing.T) {
+	input := `// doc
+let x = 1;
+x + /* c */ 2; // trailing
+fn(a /* first */, b) { a }`
+
+	l := lexer.New(input, lexer.WithComments())
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := "let x = 1;(x + 2)fn(a, b) a"
+	if program.String() != expected {
+		t.Errorf("program wrong. expected=%q, got=%q", expected, program.String())
+	}
+
+	tests := []struct {
+		expectedLiteral  string
+		expectedPosition string
+	}{
+		{"// doc", "1:1"},
+		{"/* c */", "3:5"},
+		{"// trailing", "3:16"},
+		{"/* first */", "4:6"},
+	}
+
+	if len(program.Comments) != len(tests) {
+		t.Fatalf("program.Comments wrong. expected %d comments, got=%d (%v)",
+			len(tests), len(program.Comments), program.Comments)
+	}
+
+	for i, tt := range tests {
+		comment := program.Comments[i]
+		if comment.Type != token.COMMENT {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, token.COMMENT, comment.Type)
+		}
+		if comment.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, comment.Literal)
+		}
+		if comment.Position.String() != tt.expectedPosition {
+			t.Errorf("tests[%d] - position wrong. expected=%q, got=%q", i, tt.expectedPosition, comment.Position)
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(exp.Consequence.Statements) != 1 {
+		t.Errorf("consequence is not 1 statements. got=%d\n",
+			len(exp.Consequence.Statements))
+	}
+
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			exp.Consequence.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative.Statements was not nil. got=%+v", exp.Alternative)
+	}
+}
+
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { y }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if exp.Alternative == nil || len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("exp.Alternative does not contain 1 statement. got=%+v", exp.Alternative)
+	}
+
+	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			exp.Alternative.Statements[0])
+	}
+
+	if !testIdentifier(t, alternative.Expression, "y") {
+		return
+	}
+}
+
+func TestElseIfChain(t *testing.T) {
+	input := `if (x == 0) { a } else if (x == 1) { b } else if (x == 2) { c } else { d }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+	for i, expected := range []string{"a", "b", "c"} {
+		ifExp, ok := exp.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("link %d is not ast.IfExpression. got=%T", i, exp)
+		}
+		if !testInfixExpression(t, ifExp.Condition, "x", "==", i) {
+			return
+		}
+		if ifExp.Consequence.String() != expected {
+			t.Errorf("link %d consequence wrong. expected=%q, got=%q", i, expected, ifExp.Consequence.String())
+		}
+		if ifExp.Alternative == nil || len(ifExp.Alternative.Statements) != 1 {
+			t.Fatalf("link %d has no single-statement alternative. got=%+v", i, ifExp.Alternative)
+		}
+		exp = ifExp.Alternative.Statements[0].(*ast.ExpressionStatement).Expression
+	}
+
+	if !testIdentifier(t, exp, "d") {
+		return
+	}
+
+	expected := "if(x == 0) aelse if(x == 1) belse if(x == 2) celse d"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d\n",
+			len(function.Parameters))
+	}
+
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
+			len(function.Body.Statements))
+	}
+
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ExpressionStatement. got=%T",
+			function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{input: "fn() {};", expectedParams: []string{}},
+		{input: "fn(x) {};", expectedParams: []string{"x"}},
+		{input: "fn(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+		{input: "fn(x, y,) {};", expectedParams: []string{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestBlockErrors(t *testing.T) {
+	tests := []string{
+		"fn(, ) {}",
+		"fn(x y) {}",
+		"fn(x) { x",
+		"if x { x }",
+		"if (x) { x } else",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parser errors, got none", input)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T",
+			stmt.Expression)
+	}
+
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+
+	testLiteralExpression(t, exp.Arguments[0], 1)
+	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+}
+
+func TestCallExpressionArguments(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedIdent string
+		expectedArgs  []string
+	}{
+		{input: "add();", expectedIdent: "add", expectedArgs: []string{}},
+		{input: "add(1);", expectedIdent: "add", expectedArgs: []string{"1"}},
+		{input: "add(1, 2 * 3, 4 + 5,);", expectedIdent: "add", expectedArgs: []string{"1", "(2 * 3)", "(4 + 5)"}},
+		{input: "add(\n\t1,\n\t2,\n);", expectedIdent: "add", expectedArgs: []string{"1", "2"}},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("tests[%d] - stmt.Expression is not ast.CallExpression. got=%T", i, stmt.Expression)
+		}
+
+		if !testIdentifier(t, exp.Function, tt.expectedIdent) {
+			return
+		}
+
+		if len(exp.Arguments) != len(tt.expectedArgs) {
+			t.Fatalf("tests[%d] - wrong number of arguments. want=%d, got=%d",
+				i, len(tt.expectedArgs), len(exp.Arguments))
+		}
+
+		for j, arg := range tt.expectedArgs {
+			if exp.Arguments[j].String() != arg {
+				t.Errorf("tests[%d] - argument %d wrong. want=%q, got=%q",
+					i, j, arg, exp.Arguments[j].String())
+			}
+		}
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[]", "[]"},
+		{"[1, 2 * 2, 3 + 3]", "[1, (2 * 2), (3 + 3)]"},
+		{"[1, 2, 3,]", "[1, 2, 3]"},
+		{"[[1, 2], [3, [4]], []]", "[[1, 2], [3, [4]], []]"},
+		{`["a", fn(x) { x }, {"k": [1]}]`, `["a", fn(x) x, {"k": [1]}]`},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		array, ok := stmt.Expression.(*ast.ArrayLiteral)
+		if !ok {
+			t.Fatalf("tests[%d] - exp not ast.ArrayLiteral. got=%T", i, stmt.Expression)
+		}
+
+		if array.String() != tt.expected {
+			t.Errorf("tests[%d] - array.String() wrong. expected=%q, got=%q",
+				i, tt.expected, array.String())
+		}
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{}", "{}"},
+		{`{"one": 1, "two": 2, "three": 3}`, `{"one": 1, "two": 2, "three": 3}`},
+		{`{"one": 0 + 1, "two": 10 - 8,}`, `{"one": (0 + 1), "two": (10 - 8)}`},
+		{`{1: true, false: "no", name: 2}`, `{1: true, false: "no", name: 2}`},
+		{`{"a": {"b": [1, {"c": 2}]}}`, `{"a": {"b": [1, {"c": 2}]}}`},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		hash, ok := stmt.Expression.(*ast.HashLiteral)
+		if !ok {
+			t.Fatalf("tests[%d] - exp is not ast.HashLiteral. got=%T", i, stmt.Expression)
+		}
+
+		if hash.String() != tt.expected {
+			t.Errorf("tests[%d] - hash.String() wrong. expected=%q, got=%q",
+				i, tt.expected, hash.String())
+		}
+	}
+}
+
+func TestParsingHashLiteralPairs(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("pairs[%d] - key is not ast.StringLiteral. got=%T", i, pair.Key)
+			continue
+		}
+
+		if literal.Value != expected[i].key {
+			t.Errorf("pairs[%d] - key wrong. expected=%q, got=%q", i, expected[i].key, literal.Value)
+		}
+
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestCollectionErrors(t *testing.T) {
+	tests := []string{
+		"add(1, 2",
+		"add(1 2)",
+		"[1, 2",
+		"add(,)",
+		"[1,, 2]",
+		"a[1",
+		`{"a" 1}`,
+		`{"a": 1 "b": 2}`,
+		`{"a": 1`,
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parser errors, got none", input)
+		}
+	}
+}
+
+func TestMonkeyPrograms(t *testing.T) {
+	input := `
+let fibonacci = fn(x) {
+  if (x == 0) {
+    0
+  } else {
+    if (x == 1) {
+      1
+    } else {
+      fibonacci(x - 1) + fibonacci(x - 2);
+    }
+  }
+};
+
+let map = fn(arr, f) {
+  let iter = fn(arr, accumulated) {
+    if (len(arr) == 0) {
+      accumulated
+    } else {
+      iter(rest(arr), push(accumulated, f(first(arr))));
+    }
+  };
+  iter(arr, []);
+};
+
+let people = [{"name": "Alice", "age": 24}, {"name": "Anna", "age": 28}];
+people[0]["name"];
+fn(x) { x * 2 }(5);
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 5 {
+		t.Fatalf("program.Statements does not contain 5 statements. got=%d", len(program.Statements))
+	}
+
+	expected := []string{
+		"let fibonacci = fn(x) if(x == 0) 0else if(x == 1) 1else (fibonacci((x - 1)) + fibonacci((x - 2)));",
+		"let map = fn(arr, f) let iter = fn(arr, accumulated) if(len(arr) == 0) accumulatedelse iter(rest(arr), push(accumulated, f(first(arr))));iter(arr, []);",
+		`let people = [{"name": "Alice", "age": 24}, {"name": "Anna", "age": 28}];`,
+		`((people[0])["name"])`,
+		"fn(x) (x * 2)(5)",
+	}
+
+	for i, want := range expected {
+		if got := program.Statements[i].String(); got != want {
+			t.Errorf("statements[%d] wrong.\nexpected=%q\ngot=     %q", i, want, got)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors int
+		expected       string
+	}{
+		{"let x 5; let y = 10;", 1, "let y = 10;"},
+		{"let = 5; return 7;", 1, "return 7;"},
+		{"let x 5 let y = 10;", 1, "let y = 10;"},
+		{"add(1 2); x + y;", 1, "(x + y)"},
+		{"let a = [1, 2; let b = 3;", 1, "let b = 3;"},
+		{`let h = {"a" 1}; let z = 2;`, 1, "let z = 2;"},
+		{"if (x { y } let z = 1;", 1, "let z = 1;"},
+		{"fn(x y) { x }; 5", 1, "5"},
+		{"let f = fn(x) { let = 1; x * 2 }; f(3);", 1, "let f = fn(x) (x * 2);f(3)"},
+		{"let f = fn(x) { if (x { 1 } return x; }; f(3);", 1, "let f = fn(x) return x;;f(3)"},
+		{"let x 1; let y 2; let z = 3;", 2, "let z = 3;"},
+		{"let x = 99999999999999999999; return x;", 1, "return x;"},
+		{"let f = fn() { 1", 1, ""},
+		{"for (let i = 0 i < n; i += 1) { x; } let z = 1;", 1, "let z = 1;"},
+		{"add(1, 2; let y = 3; y;", 1, "let y = 3;y"},
+		{"while (x) { let = 1; break; }", 1, "while (x) break;"},
+		{"if (x) { if (y) {", 1, "ifx "},
+		{"fn() { fn() { let x = ;", 2, "fn() "},
+		{"if (x) { 1 + }\nlet c = 2;\nlet d = 3;", 1, "ifx let c = 2;let d = 3;"},
+		{"let f = fn(x) { x + }; let g = 1;", 1, "let f = fn(x) ;let g = 1;"},
+		{"while (x) { let y = }", 1, "while (x) "},
+		{"let y = x +\nlet z = 3;\nlet w = 4;", 1, "let z = 3;let w = 4;"},
+		{"while (x) { x + while (y) {} }; let z = 1;", 1, "while (x) while (y) let z = 1;"},
+		{"for (let = 0; i < n; i += 1) {} let z = 1;", 1, "let z = 1;"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		if len(p.Errors()) != tt.expectedErrors {
+			t.Errorf("tests[%d] - wrong number of errors. expected=%d, got=%d (%q)",
+				i, tt.expectedErrors, len(p.Errors()), p.Errors())
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("tests[%d] - recovered program wrong. expected=%q, got=%q",
+				i, tt.expected, program.String())
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input               string
+		expectedCode        string
+		expectedStart       string
+		expectedEnd         string
+		expectedReplacement string // "" when no fix is suggested
+	}{
+		{"let x 5;", diagnostics.UnexpectedToken, "1:7", "1:8", "="},
+		{"(1 + 2;", diagnostics.UnexpectedToken, "1:7", "1:8", ")"},
+		{"add(1, 2;", diagnostics.UnexpectedToken, "1:9", "1:10", ""},
+		{"let x = ;", diagnostics.ExpectedExpression, "1:9", "1:10", ""},
+		{"let 5 = x;", diagnostics.UnexpectedToken, "1:5", "1:6", ""},
+		{"let x = 99999999999999999999;", diagnostics.InvalidLiteral, "1:9", "1:29", ""},
+		{"`a${}`", diagnostics.EmptyTemplateExpr, "1:3", "1:5", ""},
+		{"fn() {\n  1", diagnostics.UnclosedBlock, "2:4", "2:4", "}"},
+		{"if (x) {\n  while (y) {", diagnostics.UnclosedBlock, "2:14", "2:14", "}}"},
+		{"let x = 0xZZ;", diagnostics.MalformedNumber, "1:9", "1:13", ""},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("tests[%d] - expected a diagnostic, got none", i)
+		}
+		diagnostic := errors[0]
+
+		if diagnostic.Severity != diagnostics.Error {
+			t.Errorf("tests[%d] - severity wrong. expected=%s, got=%s", i, diagnostics.Error, diagnostic.Severity)
+		}
+		if diagnostic.Code != tt.expectedCode {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q", i, tt.expectedCode, diagnostic.Code)
+		}
+		if diagnostic.Span.Start.String() != tt.expectedStart {
+			t.Errorf("tests[%d] - span start wrong. expected=%q, got=%q", i, tt.expectedStart, diagnostic.Span.Start)
+		}
+		if diagnostic.Span.End.String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - span end wrong. expected=%q, got=%q", i, tt.expectedEnd, diagnostic.Span.End)
+		}
+
+		replacement := ""
+		if diagnostic.Fix != nil {
+			replacement = diagnostic.Fix.Replacement
+		}
+		if replacement != tt.expectedReplacement {
+			t.Errorf("tests[%d] - fix wrong. expected=%q, got=%q", i, tt.expectedReplacement, replacement)
+		}
+	}
+}
+
+func TestDiagnosticsInSourceOrder(t *testing.T) {
+	input := "let a 1;\nlet b = #;\nlet c 3;"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []string{
+		"1:7: expected next token to be =, got INT instead",
+		"2:9: illegal character '#'",
+		"3:7: expected next token to be =, got INT instead",
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of diagnostics. expected=%d, got=%d (%q)", len(expected), len(errors), errors)
+	}
+
+	for i, want := range expected {
+		if errors[i].Error() != want {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, want, errors[i].Error())
+		}
+	}
+}
+
+func TestExpectedExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x = ;", "1:9: expected an expression, got ; instead"},
+		{"return )", "1:8: expected an expression, got ) instead"},
+		{"1 + ;", "1:5: expected an expression, got ; instead"},
+		{"add(1, , 2)", "1:8: expected an expression, got , instead"},
+		{"}", "1:1: expected an expression, got } instead"},
+		{"let x = #;", "1:9: illegal character '#'"},
+		{`let s = "abc`, "1:9: unterminated string literal"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+
+		if len(program.Statements) != 0 {
+			t.Errorf("tests[%d] - broken statement was kept: %q", i, program.String())
+		}
+	}
+}
+
+func TestExpectedTokenSets(t *testing.T) {
+	// every infix operator, as listed after the expected tokens
+	operators := `"!=", "%", "%=", "&&", "(", "*", "*=", "+", "+=", "-", "-=", "/", "/=", ` +
+		`"<", "<=", "=", "==", ">", ">=", "?", "??", "[", "|>", "||"`
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedNote    string // "" for no note
+	}{
+		{"let 5 = x;", "expected next token to be IDENT, got INT instead", ""},
+		{"(1 2)", "expected next token to be ), got INT instead", `expected one of ")", ` + operators},
+		{"add(1 2)", "expected next token to be ) or ,, got INT instead", `expected one of ")", ",", ` + operators},
+		{"[1 2]", "expected next token to be ] or ,, got INT instead", `expected one of "]", ",", ` + operators},
+		{`{"a": 1 "b": 2}`, "expected next token to be } or ,, got STRING instead", `expected one of "}", ",", ` + operators},
+		{"fn(a b) {}", "expected next token to be ) or ,, got IDENT instead", ""},
+		{"if (x) { 1 } else 2", "expected next token to be { or IF, got INT instead", ""},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+		diagnostic := errors[0]
+
+		if diagnostic.Message != tt.expectedMessage {
+			t.Errorf("tests[%d] - message wrong. expected=%q, got=%q", i, tt.expectedMessage, diagnostic.Message)
+		}
+
+		if tt.expectedNote == "" {
+			if len(diagnostic.Notes) != 0 {
+				t.Errorf("tests[%d] - expected no notes, got=%q", i, diagnostic.Notes)
+			}
+			continue
+		}
+
+		if len(diagnostic.Notes) != 1 {
+			t.Fatalf("tests[%d] - expected 1 note, got=%d (%q)", i, len(diagnostic.Notes), diagnostic.Notes)
+		}
+		if diagnostic.Notes[0] != tt.expectedNote {
+			t.Errorf("tests[%d] - note wrong.\nexpected=%q\ngot=     %q", i, tt.expectedNote, diagnostic.Notes[0])
+		}
+	}
+}
+
+func TestExpectedExpressionNote(t *testing.T) {
+	l := lexer.New("let x = );")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d (%q)", len(errors), errors)
+	}
+
+	// computed from the registered prefix parse functions
+	for tokenType := range p.prefixParseFns {
+		if !strings.Contains(errors[0].Notes[0], strconv.Quote(string(tokenType))) {
+			t.Errorf("note does not list %s: %q", tokenType, errors[0].Notes[0])
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedTarget   string
+		expectedOperator string
+		expectedValue    string
+	}{
+		{"x = 5;", "x", "=", "5"},
+		{"x = x + 1", "x", "=", "(x + 1)"},
+		{"arr[i] += 2;", "(arr[i])", "+=", "2"},
+		{`hash["a"]["b"] -= 1`, `((hash["a"])["b"])`, "-=", "1"},
+		{"x *= 3", "x", "*=", "3"},
+		{"x /= 3", "x", "/=", "3"},
+		{"x %= 3", "x", "%=", "3"},
+		{"(x) = 1", "x", "=", "1"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("tests[%d] - program.Statements does not contain 1 statement. got=%d", i, len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("tests[%d] - stmt.Expression is not ast.AssignExpression. got=%T", i, stmt.Expression)
+		}
+
+		if exp.Target.String() != tt.expectedTarget {
+			t.Errorf("tests[%d] - target wrong. expected=%q, got=%q", i, tt.expectedTarget, exp.Target.String())
+		}
+		if exp.Operator != tt.expectedOperator {
+			t.Errorf("tests[%d] - operator wrong. expected=%q, got=%q", i, tt.expectedOperator, exp.Operator)
+		}
+		if exp.Value.String() != tt.expectedValue {
+			t.Errorf("tests[%d] - value wrong. expected=%q, got=%q", i, tt.expectedValue, exp.Value.String())
+		}
+	}
+}
+
+func TestInvalidAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedNote string
+	}{
+		{"1 = 2", "cannot assign to 1, only to a name like x or an index like arr[i]"},
+		{"a + b = c", "cannot assign to (a + b), only to a name like x or an index like arr[i]"},
+		{"f(x) += 1", "cannot assign to f(x), only to a name like x or an index like arr[i]"},
+		{`"s" = x; let y = 1;`, `cannot assign to "s", only to a name like x or an index like arr[i]`},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+
+		if errors[0].Code != diagnostics.InvalidAssignmentTarget {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q", i, diagnostics.InvalidAssignmentTarget, errors[0].Code)
+		}
+		if errors[0].Message != "invalid left-hand side of assignment" {
+			t.Errorf("tests[%d] - message wrong. got=%q", i, errors[0].Message)
+		}
+		if len(errors[0].Notes) != 1 || errors[0].Notes[0] != tt.expectedNote {
+			t.Errorf("tests[%d] - notes wrong. expected=%q, got=%q", i, tt.expectedNote, errors[0].Notes)
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { x += 1; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", 10) {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("body is not 1 statement. got=%d", len(stmt.Body.Statements))
+	}
+
+	if stmt.Body.String() != "(x += 1)" {
+		t.Errorf("body wrong. expected=%q, got=%q", "(x += 1)", stmt.Body.String())
+	}
+
+	// the header is printed in parentheses like those of for loops
+	if program.String() != "while ((x < 10)) (x += 1)" {
+		t.Errorf("program wrong. expected=%q, got=%q", "while ((x < 10)) (x += 1)", program.String())
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedInit      string
+		expectedCondition string
+		expectedUpdate    string
+	}{
+		{"for (let i = 0; i < n; i += 1) { puts(i); }", "let i = 0;", "(i < n)", "(i += 1)"},
+		{"for (i = 0; i < n; i = i + 1) {}", "(i = 0)", "(i < n)", "(i = (i + 1))"},
+		{"for (; i < n;) {}", "", "(i < n)", ""},
+		{"for (;;) { break; };", "", "", ""},
+		{"for (let i = 0;; i += 1) {}", "let i = 0;", "", "(i += 1)"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("tests[%d] - program.Statements does not contain 1 statement. got=%d", i, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("tests[%d] - program.Statements[0] is not ast.ForStatement. got=%T", i, program.Statements[0])
+		}
+
+		init, condition, update := "", "", ""
+		if stmt.Init != nil {
+			init = stmt.Init.String()
+		}
+		if stmt.Condition != nil {
+			condition = stmt.Condition.String()
+		}
+		if stmt.Update != nil {
+			update = stmt.Update.String()
+		}
+
+		if init != tt.expectedInit {
+			t.Errorf("tests[%d] - init wrong. expected=%q, got=%q", i, tt.expectedInit, init)
+		}
+		if condition != tt.expectedCondition {
+			t.Errorf("tests[%d] - condition wrong. expected=%q, got=%q", i, tt.expectedCondition, condition)
+		}
+		if update != tt.expectedUpdate {
+			t.Errorf("tests[%d] - update wrong. expected=%q, got=%q", i, tt.expectedUpdate, update)
+		}
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	input := `for (x in [1, 2, 3]) { if (x == 2) { continue; } puts(x); }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ForInStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForInStatement. got=%T", program.Statements[0])
+	}
+
+	if !testIdentifier(t, stmt.Variable, "x") {
+		return
+	}
+
+	if stmt.Iterable.String() != "[1, 2, 3]" {
+		t.Errorf("iterable wrong. expected=%q, got=%q", "[1, 2, 3]", stmt.Iterable.String())
+	}
+
+	expected := "for (x in [1, 2, 3]) if(x == 2) continue;puts(x)"
+	if stmt.String() != expected {
+		t.Errorf("stmt.String() wrong. expected=%q, got=%q", expected, stmt.String())
+	}
+}
+
+func TestLoopControlStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (true) { break; }", "while (true) break;"},
+		{"while (true) { continue }", "while (true) continue;"},
+		{"for (;;) { if (x) { break; } }", "for (; ; ) ifx break;"},
+		{"for (x in xs) { while (x) { break; } continue; }", "for (x in xs) while (x) break;continue;"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("tests[%d] - program wrong. expected=%q, got=%q", i, tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"let x = 1;\ncontinue", "2:1: continue outside of a loop"},
+		{"if (x) { break; }", "1:10: break outside of a loop"},
+		{"while (x) { fn() { continue; } }", "1:20: continue outside of a loop"},
+		{"while x { }", "1:7: expected next token to be (, got IDENT instead"},
+		{"for (let i = 0 i < 1;) {}", "1:16: expected next token to be ;, got IDENT instead"},
+		{"for (x in xs {}", "1:14: expected next token to be ), got { instead"},
+		{"for (i; i) {}", "1:10: expected next token to be ;, got ) instead"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	tests := []struct {
+		input          string
+		maxDepth       int
+		expectedErrors int
+	}{
+		{"((1))", 3, 0},
+		{"(((1)))", 3, 1},
+		{"--1", 3, 0},
+		{"---1", 3, 1},
+		{"fn() { 1 }", 3, 0},
+		{"fn() { fn() { 1 } }", 3, 1},
+		{"[[1]]; let x = 1;", 2, 1},
+		{strings.Repeat("(", 100000) + strings.Repeat(")", 100000), DefaultMaxDepth, 1},
+		{strings.Repeat("[", 100000), DefaultMaxDepth, 1},
+		{strings.Repeat("-", 100000) + "1", DefaultMaxDepth, 1},
+		{strings.Repeat("if (x) {", 100000) + strings.Repeat("}", 100000), DefaultMaxDepth, 1},
+		{"if (x) { 1 }" + strings.Repeat(" else if (x) { 1 }", 100000), DefaultMaxDepth, 1},
+		{strings.Repeat("a = ", 100000) + "1", DefaultMaxDepth, 1},
+		{strings.Repeat("(", 500) + "1" + strings.Repeat(")", 500), DefaultMaxDepth, 0},
+		// unbalanced input also leaves the blocks open at EOF, which is
+		// reported once for all of them
+		{strings.Repeat("if (x) {", 2000), DefaultMaxDepth, 2},
+		{strings.Repeat("while (x) {", 2000), DefaultMaxDepth, 2},
+		{strings.Repeat("fn() {", 100000), DefaultMaxDepth, 2},
+		{strings.Repeat("for (x in xs) {", 2000), DefaultMaxDepth, 2},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l, WithMaxDepth(tt.maxDepth))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != tt.expectedErrors {
+			t.Fatalf("tests[%d] - wrong number of errors. expected=%d, got=%d (%q)",
+				i, tt.expectedErrors, len(errors), errors)
+		}
+
+		if tt.expectedErrors > 0 && errors[0].Code != diagnostics.NestingTooDeep {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q (%q)",
+				i, diagnostics.NestingTooDeep, errors[0].Code, errors[0])
+		}
+
+		if tt.expectedErrors > 1 && errors[1].Code != diagnostics.UnclosedBlock {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q (%q)",
+				i, diagnostics.UnclosedBlock, errors[1].Code, errors[1])
+		}
+	}
+}
+
+func TestUnclosedBlockNotes(t *testing.T) {
+	input := "fn() {\n  if (x) {\n    while (y) {"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d (%q)", len(errors), errors)
+	}
+
+	expectedNotes := []string{
+		"the block was opened at 3:15",
+		"the enclosing block opened at 2:10 is not closed either",
+		"the enclosing block opened at 1:6 is not closed either",
+	}
+	if strings.Join(errors[0].Notes, "\n") != strings.Join(expectedNotes, "\n") {
+		t.Errorf("notes wrong. expected=%q, got=%q", expectedNotes, errors[0].Notes)
+	}
+
+	p = New(lexer.New(strings.Repeat("fn() {", 10)))
+	p.ParseProgram()
+
+	notes := p.Errors()[0].Notes
+	if len(notes) != maxUnclosedBlockNotes+2 || notes[len(notes)-1] != "and 6 more enclosing blocks" {
+		t.Errorf("notes wrong. got=%q", notes)
+	}
+}
+
+func TestMaxDepthRecovery(t *testing.T) {
+	input := "let a = 1;\nlet b = (((2)));\nlet c = 3;"
+
+	l := lexer.New(input)
+	p := New(l, WithMaxDepth(2))
+	program := p.ParseProgram()
+
+	expected := "2:11: nesting exceeds the maximum depth of 2"
+	if len(p.Errors()) != 1 || p.Errors()[0].Error() != expected {
+		t.Fatalf("errors wrong. expected=%q, got=%q", expected, p.Errors())
+	}
+
+	if program.String() != "let a = 1;let c = 3;" {
+		t.Errorf("recovered program wrong. got=%q", program.String())
+	}
+}
+
+func FuzzParseProgram(f *testing.F) {
+	seeds := []string{
+		"let x = 5; return x;",
+		"let add = fn(a, b,) { a + b }; add(1, 2 * 3)[0];",
+		`let h = {"a": [1, 2.5, 0x1F], true: !false}; h["a"] += 1;`,
+		"if (x < y) { x } else if (x == y) { 0 } else { y }",
+		"while (i < 10) { i += 1; if (i % 2 == 0) { continue; } break; }",
+		"for (let i = 0; i < n; i += 1) {} for (x in xs) { x = -x; }",
+		"let sign = x > 0 ? 1 : x < 0 ? -1 : 0; h[k] ?? null ?? fn() {}()",
+		"`hello ${name}, ${`nested ${1 + 2}`}!`",
+		"/* a /* nested */ comment */ // line\n1_000 + 1e-9",
+		"let x 5; add(1 2; fn(a b) {}; 1 = 2; break;",
+		"((((", "}}}}", "[,]", "{:}", "`${", "\"abc", "0x", "if (", "for (;;",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := lexer.New(input)
+		p := New(l, WithMaxDepth(100))
+		program := p.ParseProgram()
+
+		// everything left in the program must be printable, and every
+		// diagnostic must be renderable against the source
+		_ = program.String()
+		for _, diagnostic := range p.Errors() {
+			var out strings.Builder
+			if err := diagnostics.Render(&out, input, diagnostic); err != nil {
+				t.Fatalf("Render(%q) failed: %s", input, err)
+			}
+		}
+	})
+}
+
+func TestPipeExpression(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedFunction string
+		expectedArgs     []string
+		expected         string
+	}{
+		{"x |> f", "f", []string{"x"}, "(x |> f)"},
+		{"x |> f()", "f", []string{"x"}, "(x |> f())"},
+		{"x |> f(a, b)", "f", []string{"x", "a", "b"}, "(x |> f(a, b))"},
+		{"1 + 2 |> add(3)", "add", []string{"(1 + 2)", "3"}, "((1 + 2) |> add(3))"},
+		{"x |> (makeAdder(1))", "makeAdder(1)", []string{"x"}, "(x |> (makeAdder(1)))"},
+		{"xs |> filter(isEven) |> map(double)", "map", []string{"(xs |> filter(isEven))", "double"},
+			"((xs |> filter(isEven)) |> map(double))"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		call, ok := stmt.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("tests[%d] - stmt.Expression is not ast.CallExpression. got=%T", i, stmt.Expression)
+		}
+
+		if !call.Piped {
+			t.Errorf("tests[%d] - call is not marked as piped", i)
+		}
+
+		if call.Function.String() != tt.expectedFunction {
+			t.Errorf("tests[%d] - function wrong. expected=%q, got=%q", i, tt.expectedFunction, call.Function.String())
+		}
+
+		if len(call.Arguments) != len(tt.expectedArgs) {
+			t.Fatalf("tests[%d] - wrong number of arguments. expected=%d, got=%d",
+				i, len(tt.expectedArgs), len(call.Arguments))
+		}
+		for j, arg := range tt.expectedArgs {
+			if call.Arguments[j].String() != arg {
+				t.Errorf("tests[%d] - argument %d wrong. expected=%q, got=%q", i, j, arg, call.Arguments[j].String())
+			}
+		}
+
+		// the pipe form survives printing
+		if program.String() != tt.expected {
+			t.Errorf("tests[%d] - String() wrong. expected=%q, got=%q", i, tt.expected, program.String())
+		}
+	}
+}
+
+func TestPipeErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"x |>", "1:5: expected an expression, got EOF instead"},
+		{"x |> ;", "1:6: expected an expression, got ; instead"},
+		{"x | f", "1:3: illegal character '|'"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func TestConditionalExpression(t *testing.T) {
+	input := `x < y ? x : y`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.ConditionalExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.ConditionalExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if !testIdentifier(t, exp.Consequence, "x") {
+		return
+	}
+
+	if !testIdentifier(t, exp.Alternative, "y") {
+		return
+	}
+}
+
+func TestConditionalErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"a ? b", "1:6: expected next token to be :, got EOF instead"},
+		{"a ? b; c", "1:6: expected next token to be :, got ; instead"},
+		{"a ? : b", "1:5: expected an expression, got : instead"},
+		{"a ? b :", "1:8: expected an expression, got EOF instead"},
+		{"a ??", "1:5: expected an expression, got EOF instead"},
+		{"a ? b : c = d", "1:11: invalid left-hand side of assignment"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error, got=%d (%q)", i, len(errors), errors)
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/self-sasi/monkey-interpreter/diagnostics"
 	"github.com/self-sasi/monkey-interpreter/lexer"
 	"github.com/self-sasi/monkey-interpreter/token"
 )
@@ -27,5 +28,9 @@ func StartREPL(in io.Reader, out io.Writer) {
 		for tok := lex.NextToken(); tok.Type != token.EOF; tok = lex.NextToken() {
 			fmt.Printf("%+v\n", tok)
 		}
+
+		for _, err := range lex.Errors() {
+			diagnostics.Render(out, line, err.Diagnostic())
+		}
 	}
 }
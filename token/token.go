@@ -1,12 +1,34 @@
 package token
 
+import "fmt"
+
 // Represents the type of a lexical token.
 type TokenType string
 
 // Represents a lexical token.
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type     TokenType
+	Literal  string
+	Position Position // where the token starts in the source
+}
+
+// Represents a location in the source, i.e, the file name (if any), the
+// 1-based line and column, and the 0-based byte offset from the start of
+// the input.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+// Returns the position formatted as "file:line:column", or "line:column"
+// when no file name is known.
+func (pos Position) String() string {
+	if pos.File != "" {
+		return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+	}
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
 }
 
 // Token Types
@@ -14,9 +36,20 @@ const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF" // end of file
 
+	// Errors
+	UNTERMINATED_STRING   = "UNTERMINATED_STRING"   // "abc... without a closing quote
+	UNTERMINATED_COMMENT  = "UNTERMINATED_COMMENT"  // /* abc... without a closing */
+	MALFORMED_NUMBER      = "MALFORMED_NUMBER"      // 1.2.3, 0xZZ, 1__000
+	UNTERMINATED_TEMPLATE = "UNTERMINATED_TEMPLATE" // `abc ${x}... without a closing backtick
+
+	// Comments, only emitted when the lexer is asked to retain them
+	COMMENT = "COMMENT" // // line comment, /* block comment */
+
 	// Identifiers & literals
-	IDENT = "IDENT" // add, foo, bar, x, y, ...
-	INT   = "INT"   // integers: 12345...
+	IDENT  = "IDENT"  // add, foo, bar, x, y, ...
+	INT    = "INT"    // integers: 12345, 0xFF, 0o755, 0b1010, 1_000_000...
+	FLOAT  = "FLOAT"  // floating-point numbers: 3.14, 1e-9...
+	STRING = "STRING" // "foo bar", "tab\tnewline\n"
 
 	// Operators
 	ASSIGN   = "="
@@ -25,20 +58,47 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 	LT       = "<"
 	GT       = ">"
+	LT_EQ    = "<="
+	GT_EQ    = ">="
 	EQ       = "=="
 	NOT_EQ   = "!="
+	AND      = "&&"
+	OR       = "||"
+	PIPE     = "|>"
+	QUESTION = "?"
+	COALESCE = "??"
+
+	// Compound assignment operators
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
 
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 
 	// Paranthesis & brackets
-	LPAREN = "("
-	RPAREN = ")"
-	LBRACE = "{"
-	RBRACE = "}"
+	LPAREN   = "("
+	RPAREN   = ")"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
+
+	// Template strings, e.g, `sum: ${a + b}` is lexed as TEMPLATE_START,
+	// TEMPLATE_TEXT, TEMPLATE_EXPR_START, the tokens of "a + b",
+	// TEMPLATE_EXPR_END and TEMPLATE_END
+	TEMPLATE_START      = "TEMPLATE_START"    // opening `
+	TEMPLATE_TEXT       = "TEMPLATE_TEXT"     // literal text between the backticks
+	TEMPLATE_EXPR_START = "${"                // start of an embedded expression
+	TEMPLATE_EXPR_END   = "TEMPLATE_EXPR_END" // } closing an embedded expression
+	TEMPLATE_END        = "TEMPLATE_END"      // closing `
 
 	// Keywords
 	FUNCTION = "FUNCTION"
@@ -48,17 +108,90 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	NULL     = "NULL"
 )
 
 // The keywords that exist in the language
 var languageKeywords = map[string]TokenType{
-	"let":    LET,
-	"fn":     FUNCTION,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"let":      LET,
+	"fn":       FUNCTION,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"null":     NULL,
+}
+
+// The operators, delimiters and brackets that exist in the language, keyed
+// by their spelling. Adding an entry here is all it takes for the lexer to
+// recognise a new operator, as it always picks the longest spelling that
+// matches the input.
+var operators = map[string]TokenType{
+	"=":  ASSIGN,
+	"+":  PLUS,
+	"-":  MINUS,
+	"!":  BANG,
+	"*":  ASTERISK,
+	"/":  SLASH,
+	"%":  PERCENT,
+	"<":  LT,
+	">":  GT,
+	"<=": LT_EQ,
+	">=": GT_EQ,
+	"==": EQ,
+	"!=": NOT_EQ,
+	"&&": AND,
+	"||": OR,
+	"|>": PIPE,
+	"?":  QUESTION,
+	"??": COALESCE,
+	"+=": PLUS_ASSIGN,
+	"-=": MINUS_ASSIGN,
+	"*=": ASTERISK_ASSIGN,
+	"/=": SLASH_ASSIGN,
+	"%=": PERCENT_ASSIGN,
+	",":  COMMA,
+	";":  SEMICOLON,
+	":":  COLON,
+	"(":  LPAREN,
+	")":  RPAREN,
+	"{":  LBRACE,
+	"}":  RBRACE,
+	"[":  LBRACKET,
+	"]":  RBRACKET,
+}
+
+// The length in bytes of the longest spelling in [operators].
+var maxOperatorLength = func() int {
+	longest := 0
+	for spelling := range operators {
+		longest = max(longest, len(spelling))
+	}
+	return longest
+}()
+
+// Returns the [TokenType] for the longest operator that prefixes input,
+// along with the length of its spelling. For example, returns [LT_EQ] and 2
+// for "<=5". If input does not start with an operator, it returns
+// [ILLEGAL] and 0.
+func LookupOperator(input string) (TokenType, int) {
+	for length := min(len(input), maxOperatorLength); length > 0; length-- {
+		if tokenType, exists := operators[input[:length]]; exists {
+			return tokenType, length
+		}
+	}
+	return ILLEGAL, 0
 }
 
 // Returns the [TokenType] for identifier if it is a language keyword.